@@ -6,6 +6,8 @@ require github.com/grafana/grafana-plugin-sdk-go v0.292.0
 
 require github.com/emirpasic/gods/v2 v2.0.0-alpha
 
+require github.com/expr-lang/expr v1.17.8
+
 require (
 	github.com/BurntSushi/toml v1.5.0 // indirect
 	github.com/apache/arrow-go/v18 v18.5.2 // indirect
@@ -2,6 +2,8 @@ package main
 
 import (
 	"os"
+	// Embed the time zone database so that time zones used for parsing time fields are available in any environment
+	_ "time/tzdata"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
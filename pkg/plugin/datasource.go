@@ -181,6 +181,7 @@ func (d *Datasource) query(ctx context.Context, req *backend.QueryDataRequest, q
 		frames, errorType, err := parsing.ParseData(
 			graphQLResponse.Data,
 			parsingOption,
+			query,
 		)
 		if err != nil {
 			if errorType == parsing.FRIENDLY_ERROR {
@@ -0,0 +1,148 @@
+package parsing
+
+import (
+	"fmt"
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/util/jsonnode"
+	"math"
+	"strings"
+)
+
+// the purpose of this file is to evaluate the computed fields of a querymodel.ParsingOption using the expr language (https://expr-lang.org)
+
+// ROW_VARIABLE is the name of the variable holding every flattened key of the row, which is useful for keys that are not valid identifiers
+const ROW_VARIABLE = "__row"
+
+type computedField struct {
+	name    string
+	program *vm.Program
+	// evaluatedRows is the number of rows the computed field has been evaluated for, and failedRows is the number of those rows where it could not be evaluated
+	evaluatedRows int
+	failedRows    int
+	// firstError is the error of the first row where the computed field could not be evaluated, or nil
+	firstError error
+}
+
+// compileComputedFields compiles each computed field or returns a friendly error if an expression is invalid
+func compileComputedFields(computedFields []querymodel.ComputedField) ([]*computedField, error) {
+	var r []*computedField
+	names := map[string]bool{}
+	for _, field := range computedFields {
+		if field.Name == "" {
+			return nil, fmt.Errorf("computed field with expression: %s must have a name", field.Expression)
+		}
+		if strings.Contains(field.Name, "#") {
+			return nil, fmt.Errorf("the name of computed field: %s may not contain \"#\"", field.Name)
+		}
+		if names[field.Name] {
+			return nil, fmt.Errorf("computed field: %s has the same name as another computed field", field.Name)
+		}
+		names[field.Name] = true
+		// Fields that do not exist in a particular row evaluate to nil rather than causing an error
+		program, err := expr.Compile(field.Expression, expr.AllowUndefinedVariables())
+		if err != nil {
+			return nil, fmt.Errorf("could not compile computed field: %s error: %w", field.Name, err)
+		}
+		r = append(r, &computedField{name: field.Name, program: program})
+	}
+	return r, nil
+}
+
+// newExpressionEnvironment creates the variables available to expressions given the flattened data of a row.
+// Keys are split by the key separator so that "packet.voltage" may be referred to as packet.voltage
+func newExpressionEnvironment(flatData *jsonnode.Object, keys keyFormat) (map[string]any, error) {
+	environment := map[string]any{}
+	row := map[string]any{}
+	for _, key := range flatData.Keys() {
+		value, err := jsonnode.ToAny(flatData.Get(key), jsonnode.NUMBER_AS_INT64_OR_FLOAT64)
+		if err != nil {
+			return nil, err
+		}
+		row[key] = value
+		if strings.Contains(key, "#") {
+			continue
+		}
+		segments := strings.Split(key, keys.getSeparator())
+		parent := environment
+		for _, segment := range segments[:len(segments)-1] {
+			child, isMap := parent[segment].(map[string]any)
+			if !isMap {
+				if _, exists := parent[segment]; exists {
+					// A value is already present here, so this key can only be referred to using ROW_VARIABLE
+					parent = nil
+					break
+				}
+				child = map[string]any{}
+				parent[segment] = child
+			}
+			parent = child
+		}
+		if parent != nil {
+			name := segments[len(segments)-1]
+			if _, isMap := parent[name].(map[string]any); isMap {
+				// Nested keys are already present here, so this key can only be referred to using ROW_VARIABLE
+				continue
+			}
+			parent[name] = value
+		}
+	}
+	environment[ROW_VARIABLE] = row
+	return environment, nil
+}
+
+// applyComputedFields evaluates each computed field in order and puts its result into flatData, which allows later computed fields to use the result.
+// A computed field that cannot be evaluated for this row, such as one that multiplies a nil field, is nil. See computedFieldNotices.
+// The environment used by the expressions is returned, or nil if there are no computed fields. Any error returned is a friendly error.
+func applyComputedFields(flatData *jsonnode.Object, computedFields []*computedField, keys keyFormat) (map[string]any, error) {
+	if len(computedFields) == 0 {
+		return nil, nil
+	}
+	environment, err := newExpressionEnvironment(flatData, keys)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range computedFields {
+		if flatData.KeyExists(field.name) {
+			return nil, fmt.Errorf("computed field: %s has the same name as an existing field", field.name)
+		}
+		field.evaluatedRows++
+		result, err := expr.Run(field.program, environment)
+		if err != nil {
+			// Such as multiplying a field that is nil or a string in this row, or fetching a field from a nil object
+			result = nil
+			field.failedRows++
+			if field.firstError == nil {
+				field.firstError = err
+			}
+		}
+		if number, isFloat := result.(float64); isFloat && (math.IsNaN(number) || math.IsInf(number, 0)) {
+			// Such as the result of dividing by zero
+			result = nil
+		}
+		node, err := jsonnode.FromAny(result)
+		if err != nil {
+			return nil, fmt.Errorf("computed field: %s has an unsupported result: %v", field.name, result)
+		}
+		flatData.Put(field.name, node)
+		environment[field.name] = result
+		environment[ROW_VARIABLE].(map[string]any)[field.name] = result
+	}
+	return environment, nil
+}
+
+// computedFieldNotices returns a warning for each computed field that could not be evaluated for any row, which is often caused by a typo in its expression
+func computedFieldNotices(computedFields []*computedField) []data.Notice {
+	var r []data.Notice
+	for _, field := range computedFields {
+		if field.evaluatedRows > 0 && field.failedRows == field.evaluatedRows {
+			r = append(r, data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     fmt.Sprintf("Computed field: %s could not be evaluated for any row, so all of its values are null. First error: %v", field.name, field.firstError),
+			})
+		}
+	}
+	return r
+}
@@ -0,0 +1,139 @@
+package parsing
+
+import (
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/util/jsonnode"
+	"strings"
+	"testing"
+)
+
+func TestComputedFields(t *testing.T) {
+	jsonString := `
+{
+  "data": [
+    { "host": "a", "packet": { "voltage": 12, "current": 2.5 }, "status": "ok" },
+    { "host": "b", "packet": { "voltage": 12.5, "current": 0 }, "status": null }
+  ]
+}
+`
+	frames, err := parseTestData(t, jsonString, querymodel.ParsingOption{
+		DataPath: "data",
+		ComputedFields: []querymodel.ComputedField{
+			{Name: "power", Expression: "packet.voltage * packet.current"},
+			{Name: "resistance", Expression: "packet.voltage / packet.current"},
+			{Name: "description", Expression: `host + ": " + (status ?? "unknown")`},
+			{Name: "high", Expression: `__row["power"] > 10`},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedValues := map[string][]any{
+		"power":       {30.0, 0.0},
+		"resistance":  {4.8, nil},
+		"description": {"a: ok", "b: unknown"},
+		"high":        {true, false},
+	}
+	assertFieldValues(t, frames[0], expectedValues)
+}
+
+func TestComputedFieldsWithMissingValues(t *testing.T) {
+	jsonString := `
+{
+  "data": [
+    { "v": 1, "packet": { "voltage": 12, "current": 2.5 } },
+    { "v": null, "packet": null },
+    { "v": 3 }
+  ]
+}
+`
+	frames, err := parseTestData(t, jsonString, querymodel.ParsingOption{
+		DataPath: "data",
+		ComputedFields: []querymodel.ComputedField{
+			{Name: "double", Expression: "v * 2"},
+			{Name: "power", Expression: "packet.voltage * packet.current"},
+			{Name: "safePower", Expression: "(packet?.voltage ?? 0) * (packet?.current ?? 0)"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFieldValues(t, frames[0], map[string][]any{
+		"double":    {2.0, nil, 6.0},
+		"power":     {30.0, nil, nil},
+		"safePower": {30.0, 0.0, 0.0},
+	})
+}
+
+func TestComputedFieldNotice(t *testing.T) {
+	frames, err := parseTestData(t, `{"data": [{"v": 1, "w": 2}, {"v": 3, "w": 4}]}`, querymodel.ParsingOption{
+		DataPath: "data",
+		ComputedFields: []querymodel.ComputedField{
+			{Name: "typo", Expression: "vv * w"},
+			{Name: "product", Expression: "v * w"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := frames[0]
+	assertFieldValues(t, frame, map[string][]any{"product": {2.0, 12.0}})
+	if frame.Meta == nil || len(frame.Meta.Notices) != 1 || !strings.Contains(frame.Meta.Notices[0].Text, "typo") {
+		t.Errorf("Expected a notice naming the computed field that could not be evaluated for any row. meta: %+v", frame.Meta)
+	}
+}
+
+func TestExpressionEnvironmentKeepsNestedKeys(t *testing.T) {
+	object, err := jsonnode.Decode([]byte(`{"a.b": 1, "a": 2}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := newKeyFormat(querymodel.ParsingOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	environment, err := newExpressionEnvironment(object.(*jsonnode.Object), keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nested, isMap := environment["a"].(map[string]any); !isMap || nested["b"] != int64(1) {
+		t.Errorf("Expected a.b to still be available after the key a. environment: %v", environment)
+	}
+	if environment[ROW_VARIABLE].(map[string]any)["a"] != int64(2) {
+		t.Errorf("Expected a to be available using %s. environment: %v", ROW_VARIABLE, environment)
+	}
+}
+
+func TestComputedFieldErrors(t *testing.T) {
+	_, err := parseTestData(t, `{"data": [{"value": 1}]}`, querymodel.ParsingOption{
+		DataPath:       "data",
+		ComputedFields: []querymodel.ComputedField{{Name: "invalid", Expression: "value *"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid") {
+		t.Errorf("Expected an error naming the computed field, but got: %v", err)
+	}
+	_, err = parseTestData(t, `{"data": [{"value": 1}]}`, querymodel.ParsingOption{
+		DataPath:       "data",
+		ComputedFields: []querymodel.ComputedField{{Expression: "value * 2"}},
+	})
+	if err == nil {
+		t.Error("Expected an error for a computed field without a name")
+	}
+	_, err = parseTestData(t, `{"data": [{"v": 1, "w": 2}]}`, querymodel.ParsingOption{
+		DataPath:       "data",
+		ComputedFields: []querymodel.ComputedField{{Name: "v", Expression: "w * 10"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "same name as an existing field") {
+		t.Errorf("Expected an error for a computed field with the name of an existing field, but got: %v", err)
+	}
+	_, err = parseTestData(t, `{"data": [{"v": 1}]}`, querymodel.ParsingOption{
+		DataPath: "data",
+		ComputedFields: []querymodel.ComputedField{
+			{Name: "double", Expression: "v * 2"},
+			{Name: "double", Expression: "v * 3"},
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "same name as another computed field") {
+		t.Errorf("Expected an error for computed fields with the same name, but got: %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+package parsing
+
+import (
+	"fmt"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/util/jsonnode"
+	"strconv"
+	"strings"
+)
+
+// the purpose of this file is to evaluate JSONPath-style data paths such as items[*].readings or devices[?(@.type=="battery")].history
+// Plain dot-delimited data paths continue to be handled by getNodeFromDataPath, so that their behavior and error messages remain unchanged.
+
+type dataPathSegmentType int
+
+const (
+	// segmentMember selects a key of an object. If the key is an integer, it may also select an element of an array.
+	segmentMember dataPathSegmentType = iota
+	segmentIndex
+	segmentWildcard
+	segmentFilter
+)
+
+type dataPathSegment struct {
+	segmentType dataPathSegmentType
+	key         string
+	index       int
+	filter      filterExpression
+}
+
+// isDataPathExpression determines whether a data path uses the expression syntax.
+// GraphQL field names and aliases may only contain letters, digits and underscores, so these characters never appear in plain data paths.
+func isDataPathExpression(dataPath string) bool {
+	return strings.ContainsAny(dataPath, "[]*$")
+}
+
+func parseDataPathExpression(dataPath string) ([]dataPathSegment, error) {
+	p := &expressionParser{input: dataPath}
+	if p.peek() == '$' {
+		p.position++
+	}
+	var segments []dataPathSegment
+	for !p.atEnd() {
+		switch p.peek() {
+		case '.':
+			p.position++
+			if p.peek() == '*' {
+				p.position++
+				segments = append(segments, dataPathSegment{segmentType: segmentWildcard})
+				continue
+			}
+			name := p.readName()
+			if name == "" {
+				return nil, fmt.Errorf("expected a name at position %d of data path: %s", p.position, dataPath)
+			}
+			segments = append(segments, dataPathSegment{segmentType: segmentMember, key: name})
+		case '[':
+			p.position++
+			segment, err := p.readBracketSegment()
+			if err != nil {
+				return nil, fmt.Errorf("%w. dataPath: %s", err, dataPath)
+			}
+			segments = append(segments, segment)
+		case '*':
+			if len(segments) != 0 {
+				return nil, fmt.Errorf("unexpected '*' at position %d of data path: %s", p.position, dataPath)
+			}
+			p.position++
+			segments = append(segments, dataPathSegment{segmentType: segmentWildcard})
+		default:
+			if len(segments) != 0 {
+				return nil, fmt.Errorf("unexpected character '%c' at position %d of data path: %s", p.peek(), p.position, dataPath)
+			}
+			// The first segment does not need to be prefixed with a "."
+			name := p.readName()
+			if name == "" {
+				return nil, fmt.Errorf("unexpected character '%c' at position %d of data path: %s", p.peek(), p.position, dataPath)
+			}
+			segments = append(segments, dataPathSegment{segmentType: segmentMember, key: name})
+		}
+	}
+	return segments, nil
+}
+
+func (p *expressionParser) readBracketSegment() (dataPathSegment, error) {
+	p.skipWhitespace()
+	var segment dataPathSegment
+	switch c := p.peek(); {
+	case c == '*':
+		p.position++
+		segment = dataPathSegment{segmentType: segmentWildcard}
+	case c == '?':
+		p.position++
+		p.skipWhitespace()
+		if p.peek() != '(' {
+			return segment, fmt.Errorf("expected '(' after '?' at position %d", p.position)
+		}
+		p.position++
+		filter, err := p.parseFilterExpression()
+		if err != nil {
+			return segment, err
+		}
+		p.skipWhitespace()
+		if p.peek() != ')' {
+			return segment, fmt.Errorf("expected ')' at position %d", p.position)
+		}
+		p.position++
+		segment = dataPathSegment{segmentType: segmentFilter, filter: filter}
+	case c == '"' || c == '\'':
+		key, err := p.readQuotedString()
+		if err != nil {
+			return segment, err
+		}
+		segment = dataPathSegment{segmentType: segmentMember, key: key}
+	default:
+		index, err := p.readInteger()
+		if err != nil {
+			return segment, err
+		}
+		segment = dataPathSegment{segmentType: segmentIndex, index: index}
+	}
+	p.skipWhitespace()
+	if p.peek() != ']' {
+		return segment, fmt.Errorf("expected ']' at position %d", p.position)
+	}
+	p.position++
+	return segment, nil
+}
+
+// getNodesFromDataPath returns all the nodes matched by the data path.
+// Plain data paths always match exactly one node, or return a friendly error.
+// Data path expressions may match any number of nodes, including zero.
+func getNodesFromDataPath(graphQlResponseData *jsonnode.Object, dataPath string) ([]jsonnode.Node, error) {
+	if !isDataPathExpression(dataPath) {
+		node, err := getNodeFromDataPath(graphQlResponseData, dataPath)
+		if err != nil {
+			return nil, err
+		}
+		return []jsonnode.Node{node}, nil
+	}
+	segments, err := parseDataPathExpression(dataPath)
+	if err != nil {
+		return nil, err
+	}
+	current := []jsonnode.Node{graphQlResponseData}
+	for _, segment := range segments {
+		var next []jsonnode.Node
+		for _, node := range current {
+			next = append(next, segment.apply(node)...)
+		}
+		current = next
+	}
+	return current, nil
+}
+
+func (segment dataPathSegment) apply(node jsonnode.Node) []jsonnode.Node {
+	switch segment.segmentType {
+	case segmentMember:
+		switch typedNode := node.(type) {
+		case *jsonnode.Object:
+			if value := typedNode.Get(segment.key); value != nil {
+				return []jsonnode.Node{value}
+			}
+		case *jsonnode.Array:
+			// For compatibility with plain data paths, items.0 is the same as items[0]
+			if index, err := strconv.Atoi(segment.key); err == nil {
+				return indexArray(typedNode, index)
+			}
+		}
+	case segmentIndex:
+		if typedNode, ok := node.(*jsonnode.Array); ok {
+			return indexArray(typedNode, segment.index)
+		}
+	case segmentWildcard, segmentFilter:
+		var children []jsonnode.Node
+		switch typedNode := node.(type) {
+		case *jsonnode.Object:
+			for _, key := range typedNode.Keys() {
+				children = append(children, typedNode.Get(key))
+			}
+		case *jsonnode.Array:
+			children = *typedNode
+		}
+		if segment.segmentType == segmentWildcard {
+			return children
+		}
+		var r []jsonnode.Node
+		for _, child := range children {
+			if segment.filter.matches(child) {
+				r = append(r, child)
+			}
+		}
+		return r
+	}
+	return nil
+}
+
+// indexArray returns the element at the given index. Negative indexes count from the end of the array.
+func indexArray(array *jsonnode.Array, index int) []jsonnode.Node {
+	if index < 0 {
+		index += len(*array)
+	}
+	if index < 0 || index >= len(*array) {
+		return nil
+	}
+	return []jsonnode.Node{(*array)[index]}
+}
@@ -0,0 +1,346 @@
+package parsing
+
+import (
+	"fmt"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/util/jsonnode"
+	"strconv"
+	"strings"
+)
+
+// the purpose of this file is to parse and evaluate filters within data path expressions such as [?(@.type == "battery" && @.enabled)]
+
+type expressionParser struct {
+	input    string
+	position int
+}
+
+func (p *expressionParser) atEnd() bool {
+	return p.position >= len(p.input)
+}
+func (p *expressionParser) peek() byte {
+	if p.atEnd() {
+		return 0
+	}
+	return p.input[p.position]
+}
+func (p *expressionParser) skipWhitespace() {
+	for !p.atEnd() && (p.peek() == ' ' || p.peek() == '\t' || p.peek() == '\n') {
+		p.position++
+	}
+}
+func (p *expressionParser) consume(s string) bool {
+	if strings.HasPrefix(p.input[p.position:], s) {
+		p.position += len(s)
+		return true
+	}
+	return false
+}
+
+func isNameCharacter(c byte) bool {
+	return c == '_' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func (p *expressionParser) readName() string {
+	start := p.position
+	for !p.atEnd() && isNameCharacter(p.peek()) {
+		p.position++
+	}
+	return p.input[start:p.position]
+}
+
+func (p *expressionParser) readInteger() (int, error) {
+	start := p.position
+	if p.peek() == '-' {
+		p.position++
+	}
+	for !p.atEnd() && p.peek() >= '0' && p.peek() <= '9' {
+		p.position++
+	}
+	value, err := strconv.Atoi(p.input[start:p.position])
+	if err != nil {
+		return 0, fmt.Errorf("expected an integer at position %d", start)
+	}
+	return value, nil
+}
+
+func (p *expressionParser) readQuotedString() (string, error) {
+	start := p.position
+	quote := p.peek()
+	p.position++
+	var builder strings.Builder
+	for !p.atEnd() {
+		c := p.peek()
+		p.position++
+		if c == quote {
+			return builder.String(), nil
+		}
+		if c == '\\' && !p.atEnd() {
+			c = p.peek()
+			p.position++
+		}
+		builder.WriteByte(c)
+	}
+	return "", fmt.Errorf("unterminated string starting at position %d", start)
+}
+
+type filterExpression interface {
+	matches(node jsonnode.Node) bool
+}
+
+type filterOr struct {
+	left, right filterExpression
+}
+
+func (f filterOr) matches(node jsonnode.Node) bool {
+	return f.left.matches(node) || f.right.matches(node)
+}
+
+type filterAnd struct {
+	left, right filterExpression
+}
+
+func (f filterAnd) matches(node jsonnode.Node) bool {
+	return f.left.matches(node) && f.right.matches(node)
+}
+
+type filterNot struct {
+	inner filterExpression
+}
+
+func (f filterNot) matches(node jsonnode.Node) bool {
+	return !f.inner.matches(node)
+}
+
+// filterOperand is either a path relative to the current node (@), or a literal value
+type filterOperand struct {
+	relativePath []dataPathSegment
+	literal      jsonnode.Node
+}
+
+func (operand filterOperand) resolve(node jsonnode.Node) jsonnode.Node {
+	if operand.literal != nil {
+		return operand.literal
+	}
+	current := node
+	for _, segment := range operand.relativePath {
+		result := segment.apply(current)
+		if len(result) != 1 {
+			return nil
+		}
+		current = result[0]
+	}
+	return current
+}
+
+type filterComparison struct {
+	left filterOperand
+	// operator is blank when the left operand is tested for truthiness
+	operator string
+	right    filterOperand
+}
+
+func (f filterComparison) matches(node jsonnode.Node) bool {
+	left := f.left.resolve(node)
+	if f.operator == "" {
+		switch typedLeft := left.(type) {
+		case nil, jsonnode.Null:
+			return false
+		case jsonnode.Boolean:
+			return typedLeft.Bool()
+		}
+		return true
+	}
+	right := f.right.resolve(node)
+	switch f.operator {
+	case "==":
+		return left != nil && right != nil && jsonnode.Equal(left, right)
+	case "!=":
+		return left == nil || right == nil || !jsonnode.Equal(left, right)
+	}
+	comparison, ok := compareNodes(left, right)
+	if !ok {
+		return false
+	}
+	switch f.operator {
+	case "<":
+		return comparison < 0
+	case "<=":
+		return comparison <= 0
+	case ">":
+		return comparison > 0
+	case ">=":
+		return comparison >= 0
+	}
+	return false
+}
+
+// compareNodes compares two numbers or two strings. ok is false if the nodes cannot be compared.
+func compareNodes(a jsonnode.Node, b jsonnode.Node) (result int, ok bool) {
+	switch typedA := a.(type) {
+	case jsonnode.Number:
+		typedB, isNumber := b.(jsonnode.Number)
+		if !isNumber {
+			return 0, false
+		}
+		floatA, errA := typedA.Float64()
+		floatB, errB := typedB.Float64()
+		if errA != nil || errB != nil {
+			return 0, false
+		}
+		if floatA < floatB {
+			return -1, true
+		} else if floatA > floatB {
+			return 1, true
+		}
+		return 0, true
+	case jsonnode.String:
+		typedB, isString := b.(jsonnode.String)
+		if !isString {
+			return 0, false
+		}
+		return strings.Compare(typedA.String(), typedB.String()), true
+	}
+	return 0, false
+}
+
+// parseFilterExpression parses an expression with this grammar:
+//
+//	or         := and ('||' and)*
+//	and        := unary ('&&' unary)*
+//	unary      := '!' unary | '(' or ')' | comparison
+//	comparison := operand (('==' | '!=' | '<' | '<=' | '>' | '>=') operand)?
+//	operand    := '@' relativePath | string | number | 'true' | 'false' | 'null'
+func (p *expressionParser) parseFilterExpression() (filterExpression, error) {
+	left, err := p.parseFilterAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipWhitespace()
+		if !p.consume("||") {
+			return left, nil
+		}
+		right, err := p.parseFilterAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = filterOr{left: left, right: right}
+	}
+}
+
+func (p *expressionParser) parseFilterAnd() (filterExpression, error) {
+	left, err := p.parseFilterUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipWhitespace()
+		if !p.consume("&&") {
+			return left, nil
+		}
+		right, err := p.parseFilterUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = filterAnd{left: left, right: right}
+	}
+}
+
+func (p *expressionParser) parseFilterUnary() (filterExpression, error) {
+	p.skipWhitespace()
+	if p.peek() == '!' && !strings.HasPrefix(p.input[p.position:], "!=") {
+		p.position++
+		inner, err := p.parseFilterUnary()
+		if err != nil {
+			return nil, err
+		}
+		return filterNot{inner: inner}, nil
+	}
+	if p.peek() == '(' {
+		p.position++
+		inner, err := p.parseFilterExpression()
+		if err != nil {
+			return nil, err
+		}
+		p.skipWhitespace()
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("expected ')' at position %d", p.position)
+		}
+		p.position++
+		return inner, nil
+	}
+	left, err := p.parseFilterOperand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipWhitespace()
+	for _, operator := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(operator) {
+			right, err := p.parseFilterOperand()
+			if err != nil {
+				return nil, err
+			}
+			return filterComparison{left: left, operator: operator, right: right}, nil
+		}
+	}
+	if left.literal != nil {
+		return nil, fmt.Errorf("expected a comparison operator at position %d", p.position)
+	}
+	return filterComparison{left: left}, nil
+}
+
+func (p *expressionParser) parseFilterOperand() (filterOperand, error) {
+	p.skipWhitespace()
+	start := p.position
+	switch c := p.peek(); {
+	case c == '@':
+		p.position++
+		var relativePath []dataPathSegment
+		for {
+			if p.peek() == '.' {
+				p.position++
+				name := p.readName()
+				if name == "" {
+					return filterOperand{}, fmt.Errorf("expected a name at position %d", p.position)
+				}
+				relativePath = append(relativePath, dataPathSegment{segmentType: segmentMember, key: name})
+			} else if p.peek() == '[' {
+				p.position++
+				segment, err := p.readBracketSegment()
+				if err != nil {
+					return filterOperand{}, err
+				}
+				if segment.segmentType != segmentMember && segment.segmentType != segmentIndex {
+					return filterOperand{}, fmt.Errorf("only names and indexes may be used in a filter path at position %d", start)
+				}
+				relativePath = append(relativePath, segment)
+			} else {
+				return filterOperand{relativePath: relativePath}, nil
+			}
+		}
+	case c == '"' || c == '\'':
+		value, err := p.readQuotedString()
+		if err != nil {
+			return filterOperand{}, err
+		}
+		return filterOperand{literal: jsonnode.String(value)}, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		for !p.atEnd() && strings.IndexByte("+-.0123456789eE", p.peek()) >= 0 {
+			p.position++
+		}
+		literal := p.input[start:p.position]
+		if _, err := strconv.ParseFloat(literal, 64); err != nil {
+			return filterOperand{}, fmt.Errorf("invalid number %s at position %d", literal, start)
+		}
+		return filterOperand{literal: jsonnode.Number(literal)}, nil
+	}
+	switch {
+	case p.consume("true"):
+		return filterOperand{literal: jsonnode.Boolean(true)}, nil
+	case p.consume("false"):
+		return filterOperand{literal: jsonnode.Boolean(false)}, nil
+	case p.consume("null"):
+		return filterOperand{literal: jsonnode.NULL}, nil
+	}
+	return filterOperand{}, fmt.Errorf("expected a value or @ at position %d", start)
+}
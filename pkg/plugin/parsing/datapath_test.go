@@ -0,0 +1,103 @@
+package parsing
+
+import (
+	"encoding/json"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/util/jsonnode"
+	"testing"
+)
+
+const dataPathTestJson = `
+{
+  "devices": [
+    {
+      "name": "battery 1",
+      "type": "battery",
+      "capacity": 100,
+      "history": [{ "dateMillis": 1, "value": 12.5 }, { "dateMillis": 2, "value": 12.6 }]
+    },
+    {
+      "name": "panel 1",
+      "type": "solar",
+      "capacity": 300,
+      "history": [{ "dateMillis": 1, "value": 40.1 }]
+    },
+    {
+      "name": "battery 2",
+      "type": "battery",
+      "capacity": 200,
+      "history": [{ "dateMillis": 3, "value": 13.1 }]
+    }
+  ]
+}
+`
+
+func getDataPathTestObject(t *testing.T) *jsonnode.Object {
+	var object = jsonnode.NewObject()
+	err := json.Unmarshal([]byte(dataPathTestJson), &object)
+	if err != nil {
+		t.Fatal("Could not unmarshal JSON", err)
+	}
+	return object
+}
+
+func TestDataPathExpressions(t *testing.T) {
+	object := getDataPathTestObject(t)
+	for dataPath, expectedMatches := range map[string]int{
+		"devices":                               1,
+		"devices[*].history":                    3,
+		"$.devices.*.history":                   3,
+		"devices[1].history":                    1,
+		"devices[-1].name":                      1,
+		"devices[5].history":                    0,
+		`devices[?(@.type=="battery")].history`: 2,
+		`devices[?(@.type == 'battery' && @.capacity > 150)]`:  1,
+		`devices[?(@.type != "battery" || @.capacity <= 100)]`: 2,
+		`devices[?(!(@.type == "battery"))]`:                   1,
+		`devices[?(@.missing)]`:                                0,
+		`devices[*]["name"]`:                                   3,
+	} {
+		nodes, err := getNodesFromDataPath(object, dataPath)
+		if err != nil {
+			t.Errorf("Unexpected error for data path %s: %v", dataPath, err)
+			continue
+		}
+		if len(nodes) != expectedMatches {
+			t.Errorf("Data path %s matched %d nodes but we expected %d", dataPath, len(nodes), expectedMatches)
+		}
+	}
+}
+
+func TestDataPathExpressionErrors(t *testing.T) {
+	object := getDataPathTestObject(t)
+	for _, dataPath := range []string{
+		"devices[",
+		"devices[abc]",
+		`devices[?(@.type == )]`,
+		`devices[?(@.type == "battery"]`,
+		"devices[*]history",
+	} {
+		_, err := getNodesFromDataPath(object, dataPath)
+		if err == nil {
+			t.Errorf("Expected an error for data path %s", dataPath)
+		}
+	}
+}
+
+func TestParseDataConcatenatesDataPathExpressionMatches(t *testing.T) {
+	object := getDataPathTestObject(t)
+	frames, _, err := ParseData(object, querymodel.ParsingOption{
+		DataPath:   `devices[?(@.type=="battery")].history`,
+		TimeFields: []querymodel.TimeField{{TimePath: "dateMillis"}},
+	}, backend.DataQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 1 {
+		t.Fatalf("Unexpected number of frames: %d", len(frames))
+	}
+	if frames[0].Rows() != 3 {
+		t.Errorf("Unexpected number of rows: %d", frames[0].Rows())
+	}
+}
@@ -0,0 +1,98 @@
+package parsing
+
+import (
+	"fmt"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/parsing/framemap"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+)
+
+// the purpose of this file is to configure the derived fields and downsampling of a querymodel.ParsingOption on a framemap.FrameMap
+
+// primaryTimeField returns the key of the first time field, or a blank string if there are no time fields.
+// Configured time fields come first, then fields with a time type hint, then automatically detected time fields.
+func primaryTimeField(parsingOption querymodel.ParsingOption, detectedKeys []string) string {
+	for _, timeField := range parsingOption.TimeFields {
+		if timeField.TimePath != "" {
+			return timeField.TimePath
+		}
+	}
+	for _, fieldTypeHint := range parsingOption.FieldTypes {
+		if fieldTypeHint.Type == querymodel.TIME && fieldTypeHint.FieldPath != "" {
+			return fieldTypeHint.FieldPath
+		}
+	}
+	if len(detectedKeys) > 0 {
+		return detectedKeys[0]
+	}
+	return ""
+}
+
+// addDerivedFields adds each derived field to fm, or returns a friendly error if a derived field is misconfigured
+func addDerivedFields(fm *framemap.FrameMap, parsingOption querymodel.ParsingOption, detectedKeys []string) error {
+	for _, derivedField := range parsingOption.DerivedFields {
+		if derivedField.Name == "" || derivedField.FieldPath == "" {
+			return fmt.Errorf("derived fields must have a name and a field path")
+		}
+		timeField := derivedField.TimePath
+		if timeField == "" {
+			timeField = primaryTimeField(parsingOption, detectedKeys)
+		}
+		var derivedFieldType framemap.DerivedFieldType
+		switch derivedField.Type {
+		case querymodel.DELTA, "":
+			derivedFieldType = framemap.DERIVE_DELTA
+		case querymodel.RATE:
+			derivedFieldType = framemap.DERIVE_RATE
+		case querymodel.NON_NEGATIVE_DERIVATIVE:
+			derivedFieldType = framemap.DERIVE_NON_NEGATIVE_DERIVATIVE
+		default:
+			return fmt.Errorf("unknown type: %s for derived field: %s", derivedField.Type, derivedField.Name)
+		}
+		if derivedFieldType != framemap.DERIVE_DELTA && timeField == "" {
+			return fmt.Errorf("derived field: %s requires a time field", derivedField.Name)
+		}
+		err := fm.AddDerivedField(framemap.DerivedField{
+			Name:          derivedField.Name,
+			Field:         derivedField.FieldPath,
+			TimeField:     timeField,
+			Type:          derivedFieldType,
+			CounterResets: derivedField.CounterResets,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setDownsampling configures the downsampling of fm, or returns a friendly error if downsampling is misconfigured
+func setDownsampling(fm *framemap.FrameMap, parsingOption querymodel.ParsingOption, detectedKeys []string, query backend.DataQuery) error {
+	var method framemap.DownsampleMethod
+	switch parsingOption.Downsampling {
+	case querymodel.NO_DOWNSAMPLING, "":
+		return nil
+	case querymodel.LTTB:
+		method = framemap.DOWNSAMPLE_LTTB
+	case querymodel.MEAN:
+		method = framemap.DOWNSAMPLE_MEAN
+	case querymodel.MIN_MAX:
+		method = framemap.DOWNSAMPLE_MIN_MAX
+	case querymodel.LAST:
+		method = framemap.DOWNSAMPLE_LAST
+	default:
+		return fmt.Errorf("unknown downsampling method: %s", parsingOption.Downsampling)
+	}
+	timeField := primaryTimeField(parsingOption, detectedKeys)
+	if timeField == "" {
+		return fmt.Errorf("downsampling requires a time field")
+	}
+	fm.SetDownsampling(&framemap.Downsampling{
+		Method:     method,
+		TimeField:  timeField,
+		ValueField: parsingOption.DownsampleFieldPath,
+		MaxPoints:  int(query.MaxDataPoints),
+		Interval:   query.Interval,
+	})
+	return nil
+}
@@ -0,0 +1,109 @@
+package parsing
+
+import (
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"strings"
+	"testing"
+)
+
+func TestDerivedFields(t *testing.T) {
+	jsonString := `
+{
+  "data": [
+    { "time": 1704067260000, "device": "a", "energy": 160 },
+    { "time": 1704067200000, "device": "a", "energy": 100 },
+    { "time": 1704067200000, "device": "b", "energy": 5 },
+    { "time": 1704067320000, "device": "a", "energy": 40 },
+    { "time": 1704067380000, "device": "a", "energy": null },
+    { "time": 1704067440000, "device": "a", "energy": 100 }
+  ]
+}
+`
+	frames, err := parseTestData(t, jsonString, querymodel.ParsingOption{
+		DataPath:   "data",
+		TimeFields: []querymodel.TimeField{{TimePath: "time"}},
+		DerivedFields: []querymodel.DerivedField{
+			{Name: "delta", FieldPath: "energy", Type: querymodel.DELTA},
+			{Name: "rate", FieldPath: "energy", Type: querymodel.RATE, CounterResets: true},
+			{Name: "derivative", FieldPath: "energy", Type: querymodel.NON_NEGATIVE_DERIVATIVE},
+		},
+		LabelOptions: []querymodel.LabelOption{{Name: "device", Type: querymodel.FIELD, Value: "device"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 2 {
+		t.Fatalf("Expected 2 frames, but got %d", len(frames))
+	}
+	frame := frames[0]
+	var fieldNames []string
+	for _, field := range frame.Fields {
+		fieldNames = append(fieldNames, field.Name)
+	}
+	if strings.Join(fieldNames, ",") != "time,device,energy,delta,rate,derivative" {
+		t.Errorf("Unexpected field order: %v", fieldNames)
+	}
+	// The rows remain in response order, but the values are derived in time order
+	assertFieldValues(t, frame, map[string][]any{
+		"delta":      {60.0, nil, -120.0, nil, 60.0},
+		"rate":       {1.0, nil, 40.0 / 60, nil, 0.5},
+		"derivative": {1.0, nil, nil, nil, 0.5},
+	})
+	delta, _ := frames[1].FieldByName("delta")
+	if delta == nil || delta.Len() != 1 {
+		t.Fatal("Expected the series of device b to be derived separately")
+	}
+	if _, ok := delta.ConcreteAt(0); ok {
+		t.Error("Expected the first value of a series to be null")
+	}
+}
+
+func TestDerivedFieldsAreNumeric(t *testing.T) {
+	// Each series has a single row, such as the response of an instant query, so every derived value is null
+	frames, err := parseTestData(t, `{"data": [{"device": "a", "energy": 1}, {"device": "b", "energy": "unknown"}]}`, querymodel.ParsingOption{
+		DataPath:        "data",
+		DerivedFields:   []querymodel.DerivedField{{Name: "delta", FieldPath: "energy"}},
+		LabelOptions:    []querymodel.LabelOption{{Name: "device", Type: querymodel.FIELD, Value: "device"}},
+		MixedTypePolicy: querymodel.PROMOTE_TO_STRING,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, frame := range frames {
+		field, _ := frame.FieldByName("delta")
+		if field == nil || field.Type() != data.FieldTypeNullableFloat64 {
+			t.Errorf("Expected the derived field of frame: %s to be a nullable float64 field", frame.Name)
+		}
+	}
+}
+
+func TestDerivedFieldErrors(t *testing.T) {
+	_, err := parseTestData(t, `{"data": [{"energy": 1}]}`, querymodel.ParsingOption{
+		DataPath:      "data",
+		DerivedFields: []querymodel.DerivedField{{Name: "rate", FieldPath: "energy", Type: querymodel.RATE}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "requires a time field") {
+		t.Errorf("Expected an error for a rate without a time field, but got: %v", err)
+	}
+	for _, derivedFields := range [][]querymodel.DerivedField{
+		{{Name: "energy", FieldPath: "energy"}},
+		{{Name: "value", FieldPath: "energy"}},
+		{{Name: "delta", FieldPath: "energy"}, {Name: "delta", FieldPath: "value"}},
+	} {
+		_, err = parseTestData(t, `{"data": [{"energy": 1, "value": 2}]}`, querymodel.ParsingOption{
+			DataPath:      "data",
+			DerivedFields: derivedFields,
+		})
+		if err == nil || !strings.Contains(err.Error(), "same name") {
+			t.Errorf("Expected an error for derived field: %s that has the name of another field, but got: %v", derivedFields[len(derivedFields)-1].Name, err)
+		}
+	}
+	_, err = parseTestData(t, `{"data": [{"energy": 1}]}`, querymodel.ParsingOption{
+		DataPath:      "data",
+		DerivedFields: []querymodel.DerivedField{{Name: "delta", FieldPath: "enrgy"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "does not exist in any row") {
+		t.Errorf("Expected an error for a derived field whose field does not exist, but got: %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package parsing
+
+import (
+	"fmt"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/util/jsonnode"
+	"strings"
+	"testing"
+	"time"
+)
+
+// downsampleTestData returns 100 points one second apart, where the value is the index of the point other than a spike of 1000 at index 55
+func downsampleTestData() *jsonnode.Object {
+	var elements []string
+	for i := 0; i < 100; i++ {
+		value := i
+		if i == 55 {
+			value = 1000
+		}
+		elements = append(elements, fmt.Sprintf(`{"time": %d, "value": %d, "name": "point %d"}`, 1704067200000+i*1000, value, i))
+	}
+	object, _ := jsonnode.Decode([]byte(`{"data": [` + strings.Join(elements, ",") + `]}`))
+	return object.(*jsonnode.Object)
+}
+
+func parseDownsampleTestData(t *testing.T, method querymodel.DownsampleMethod, maxDataPoints int64, interval time.Duration) *data.Frame {
+	frames, _, err := ParseData(downsampleTestData(), querymodel.ParsingOption{
+		DataPath:     "data",
+		TimeFields:   []querymodel.TimeField{{TimePath: "time"}},
+		Downsampling: method,
+	}, backend.DataQuery{RefID: "A", MaxDataPoints: maxDataPoints, Interval: interval})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return frames[0]
+}
+
+func TestDownsampleLast(t *testing.T) {
+	frame := parseDownsampleTestData(t, querymodel.LAST, 10, 10*time.Second)
+	values := valuesOf(frame, "value")
+	expected := []any{9.0, 19.0, 29.0, 39.0, 49.0, 59.0, 69.0, 79.0, 89.0, 99.0}
+	if fmt.Sprint(values) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v but got %v", expected, values)
+	}
+}
+
+func TestDownsampleMean(t *testing.T) {
+	frame := parseDownsampleTestData(t, querymodel.MEAN, 10, 10*time.Second)
+	values := valuesOf(frame, "value")
+	if len(values) != 10 || values[0] != 4.5 || values[1] != 14.5 || values[9] != 94.5 {
+		t.Errorf("Unexpected means: %v", values)
+	}
+	times := valuesOf(frame, "time")
+	if times[1] != time.UnixMilli(1704067210000).UTC() {
+		t.Errorf("Expected the time to be the time of the first point of the bucket, but was %v", times[1])
+	}
+	if names := valuesOf(frame, "name"); names[0] != "point 9" {
+		t.Errorf("Expected fields that are not numeric to have the last value, but got %v", names[0])
+	}
+}
+
+func TestDownsampleBucketTimes(t *testing.T) {
+	object, _ := jsonnode.Decode([]byte(`{"data": [{"time": 1000, "value": 1}, {"time": 2000, "value": 2}, {"time": 3000, "value": 3}, {"time": 4000, "value": 4}]}`))
+	for _, interval := range []time.Duration{0, time.Second} {
+		frames, _, err := ParseData(object.(*jsonnode.Object), querymodel.ParsingOption{
+			DataPath:     "data",
+			TimeFields:   []querymodel.TimeField{{TimePath: "time"}},
+			Downsampling: querymodel.MEAN,
+		}, backend.DataQuery{RefID: "A", MaxDataPoints: 2, Interval: interval})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The buckets are [1s, 3s) and [3s, 5s), so each point is at the time of the first point of its bucket
+		expectedTimes := []any{time.UnixMilli(1000).UTC(), time.UnixMilli(3000).UTC()}
+		if times := valuesOf(frames[0], "time"); fmt.Sprint(times) != fmt.Sprint(expectedTimes) {
+			t.Errorf("Expected times %v with interval %v but got %v", expectedTimes, interval, times)
+		}
+		if values := valuesOf(frames[0], "value"); fmt.Sprint(values) != fmt.Sprint([]any{1.5, 3.5}) {
+			t.Errorf("Unexpected means with interval %v: %v", interval, values)
+		}
+	}
+
+	// With an interval of 3 seconds, the buckets are aligned to multiples of 3 seconds since the epoch, which are [0s, 3s) and [3s, 6s)
+	frames, _, err := ParseData(object.(*jsonnode.Object), querymodel.ParsingOption{
+		DataPath:     "data",
+		TimeFields:   []querymodel.TimeField{{TimePath: "time"}},
+		Downsampling: querymodel.LAST,
+	}, backend.DataQuery{RefID: "A", MaxDataPoints: 2, Interval: 3 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values := valuesOf(frames[0], "value"); fmt.Sprint(values) != fmt.Sprint([]any{2.0, 4.0}) {
+		t.Errorf("Expected the buckets to be aligned to multiples of the interval, but got %v", values)
+	}
+}
+
+func TestDownsampleMinMax(t *testing.T) {
+	frame := parseDownsampleTestData(t, querymodel.MIN_MAX, 10, time.Second)
+	values := valuesOf(frame, "value")
+	if len(values) > 10 || values[0] != 0.0 || values[len(values)-1] != 99.0 || !strings.Contains(fmt.Sprint(values), "1000") {
+		t.Errorf("Expected at most 10 values including the minimum, maximum and spike: %v", values)
+	}
+}
+
+func TestDownsampleLttb(t *testing.T) {
+	frame := parseDownsampleTestData(t, querymodel.LTTB, 10, 0)
+	values := valuesOf(frame, "value")
+	if len(values) != 10 || values[0] != 0.0 || values[9] != 99.0 || !strings.Contains(fmt.Sprint(values), "1000") {
+		t.Errorf("Expected 10 values including the first, last and spike: %v", values)
+	}
+}
+
+func TestDownsampleLttbNullFirstValue(t *testing.T) {
+	var elements []string
+	for i := 0; i < 1000; i++ {
+		value := fmt.Sprint(i % 7)
+		if i == 0 {
+			value = "null"
+		}
+		elements = append(elements, fmt.Sprintf(`{"time": %d, "value": %s}`, 1704067200000+i*1000, value))
+	}
+	object, _ := jsonnode.Decode([]byte(`{"data": [` + strings.Join(elements, ",") + `]}`))
+	frames, _, err := ParseData(object.(*jsonnode.Object), querymodel.ParsingOption{
+		DataPath:     "data",
+		TimeFields:   []querymodel.TimeField{{TimePath: "time"}},
+		Downsampling: querymodel.LTTB,
+	}, backend.DataQuery{RefID: "A", MaxDataPoints: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frames[0].Rows() != 100 {
+		t.Errorf("Expected 100 rows even though the first value is null, but got %d", frames[0].Rows())
+	}
+}
+
+func TestDownsampleLttbSmallThreshold(t *testing.T) {
+	for _, maxDataPoints := range []int64{1, 2} {
+		frame := parseDownsampleTestData(t, querymodel.LTTB, maxDataPoints, 0)
+		if frame.Rows() != int(maxDataPoints) {
+			t.Errorf("Expected %d rows but got %d", maxDataPoints, frame.Rows())
+		}
+	}
+}
+
+func TestDownsampleNotNeeded(t *testing.T) {
+	frame := parseDownsampleTestData(t, querymodel.LTTB, 100, 0)
+	if frame.Rows() != 100 {
+		t.Errorf("Expected frames with at most max data points rows to not be downsampled, but got %d rows", frame.Rows())
+	}
+}
+
+func TestDownsampleErrors(t *testing.T) {
+	_, err := parseTestData(t, `{"data": [{"value": 1}]}`, querymodel.ParsingOption{DataPath: "data", Downsampling: querymodel.LTTB})
+	if err == nil || !strings.Contains(err.Error(), "requires a time field") {
+		t.Errorf("Expected an error when there is no time field, but got: %v", err)
+	}
+}
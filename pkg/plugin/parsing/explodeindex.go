@@ -0,0 +1,63 @@
+package parsing
+
+import (
+	"fmt"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/util/jsonnode"
+	"strconv"
+	"strings"
+)
+
+// the purpose of this file is to expose the index of each element of an exploded array as a field or a label
+
+// EXPLODE_INDEX_SUFFIX is appended to the path of an exploded array to create the key holding the index of each element.
+// Because the key includes "#" it is not included in the data frame unless querymodel.ParsingOption.ExplodeIndexMode is INDEX_AS_FIELD
+const EXPLODE_INDEX_SUFFIX = "#index"
+
+// explodeIndex is the index of an exploded array element that is exposed as a field or a label
+type explodeIndex struct {
+	key   string
+	value int
+}
+
+// explodeIndexes returns the indexes of flatData's exploded array elements, or nil if the index is not exposed.
+// Only keys written by flattenOptions.putExplodeIndex are used, and any error returned is a friendly error.
+// Unless querymodel.ParsingOption.ExplodeParentIndexes is true, the indexes of exploded arrays that contain other exploded arrays are omitted.
+func explodeIndexes(flatData *jsonnode.Object, parsingOption querymodel.ParsingOption, options *flattenOptions) ([]explodeIndex, error) {
+	if parsingOption.ExplodeIndexMode != querymodel.INDEX_AS_FIELD && parsingOption.ExplodeIndexMode != querymodel.INDEX_AS_LABEL {
+		return nil, nil
+	}
+	var indexKeys []string
+	for _, key := range flatData.Keys() {
+		if options.explodeIndexKeys[key] {
+			indexKeys = append(indexKeys, key)
+		}
+	}
+	var r []explodeIndex
+	for _, key := range indexKeys {
+		if !parsingOption.ExplodeParentIndexes {
+			prefix := options.keys.prefixOf(strings.TrimSuffix(key, EXPLODE_INDEX_SUFFIX))
+			isParent := false
+			for _, otherKey := range indexKeys {
+				if strings.HasPrefix(otherKey, prefix) {
+					isParent = true
+					break
+				}
+			}
+			if isParent {
+				continue
+			}
+		}
+		// The key of a value in the response may be the same as the key of an index, such as "values#index" next to an exploded "values" array
+		value, isString := flatData.Get(key).(jsonnode.String)
+		if !isString {
+			return nil, fmt.Errorf("field: %s has the same key as the index of an exploded array", key)
+		}
+		index, err := strconv.Atoi(value.String())
+		if err != nil {
+			return nil, fmt.Errorf("field: %s has the same key as the index of an exploded array", key)
+		}
+		r = append(r, explodeIndex{key: key, value: index})
+	}
+	return r, nil
+}
@@ -0,0 +1,133 @@
+package parsing
+
+import (
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/util/jsonnode"
+	"strings"
+	"testing"
+)
+
+const explodeIndexTestData = `
+{
+  "data": [
+    { "series": [ { "points": [ { "value": 1 }, { "value": 2 } ] }, { "points": [ { "value": 3 } ] } ] }
+  ]
+}
+`
+
+func TestExplodeIndexAsField(t *testing.T) {
+	frames, err := parseTestData(t, explodeIndexTestData, querymodel.ParsingOption{
+		DataPath:          "data",
+		ExplodeArrayPaths: []string{"series.points"},
+		ExplodeIndexMode:  querymodel.INDEX_AS_FIELD,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := frames[0]
+	if len(frame.Fields) != 2 {
+		t.Fatalf("Expected 2 fields but got %d", len(frame.Fields))
+	}
+	field, _ := frame.FieldByName("series.points#index")
+	if field == nil {
+		t.Fatal("Expected the index of series.points to be a field")
+	}
+	for i, expected := range []int64{0, 1, 0} {
+		if actual, _ := field.ConcreteAt(i); actual != expected {
+			t.Errorf("Expected index %d to be %d but was %v", i, expected, actual)
+		}
+	}
+
+	frames, err = parseTestData(t, explodeIndexTestData, querymodel.ParsingOption{
+		DataPath:             "data",
+		ExplodeArrayPaths:    []string{"series.points"},
+		ExplodeIndexMode:     querymodel.INDEX_AS_FIELD,
+		ExplodeParentIndexes: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	field, _ = frames[0].FieldByName("series#index")
+	if field == nil {
+		t.Fatal("Expected the index of series to be a field")
+	}
+	for i, expected := range []int64{0, 0, 1} {
+		if actual, _ := field.ConcreteAt(i); actual != expected {
+			t.Errorf("Expected parent index %d to be %d but was %v", i, expected, actual)
+		}
+	}
+}
+
+func TestExplodeIndexAsLabel(t *testing.T) {
+	frames, err := parseTestData(t, explodeIndexTestData, querymodel.ParsingOption{
+		DataPath:             "data",
+		ExplodeArrayPaths:    []string{"series.points"},
+		ExplodeIndexMode:     querymodel.INDEX_AS_LABEL,
+		ExplodeParentIndexes: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 3 {
+		t.Fatalf("Expected 3 frames but got %d", len(frames))
+	}
+	labels := frames[2].Fields[0].Labels
+	if labels["series#index"] != "1" || labels["series.points#index"] != "0" {
+		t.Errorf("Unexpected labels: %v", labels)
+	}
+}
+
+func TestExplodeIndexNotExposed(t *testing.T) {
+	object, err := jsonnode.Decode([]byte(explodeIndexTestData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	options, err := newFlattenOptions(querymodel.ParsingOption{ExplodeArrayPaths: []string{"series.points"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := flattenAndExplode((*object.(*jsonnode.Object).Get("data").(*jsonnode.Array))[0].(*jsonnode.Object), "", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 rows but got %d", len(results))
+	}
+	for _, result := range results {
+		if keys := result.Keys(); len(keys) != 1 || keys[0] != "series.points.value" {
+			t.Errorf("Expected only the series.points.value key when the index is not exposed but got %v", keys)
+		}
+	}
+
+	if _, err := parseTestData(t, explodeIndexTestData, querymodel.ParsingOption{
+		DataPath:          "data",
+		ExplodeArrayPaths: []string{"series.points"},
+		ExplodeIndexMode:  "column",
+	}); err == nil {
+		t.Error("Expected an error for an unknown explode index mode")
+	}
+}
+
+func TestExplodeIndexWithIndexLikeKeys(t *testing.T) {
+	for _, mode := range []querymodel.ExplodeIndexMode{querymodel.INDEX_AS_FIELD, querymodel.INDEX_AS_LABEL} {
+		frames, err := parseTestData(t, `{"data": [{"meta": {"x#index": 5}, "v": 1}]}`, querymodel.ParsingOption{
+			DataPath:         "data",
+			ExplodeIndexMode: mode,
+		})
+		if err != nil {
+			t.Fatalf("Expected a key ending in %s that is not an index to be ignored, but got: %v", EXPLODE_INDEX_SUFFIX, err)
+		}
+		if len(frames) != 1 || len(frames[0].Fields) != 1 || len(frames[0].Fields[0].Labels) != 0 {
+			t.Errorf("Expected only the v field without labels for mode: %s", mode)
+		}
+	}
+
+	_, err := parseTestData(t, `{"data": [{"values": [1, 2], "values#index": 5}]}`, querymodel.ParsingOption{
+		DataPath:          "data",
+		ExplodeArrayPaths: []string{"values"},
+		ExplodeIndexMode:  querymodel.INDEX_AS_LABEL,
+	})
+	if err == nil || !strings.Contains(err.Error(), "values#index") {
+		t.Errorf("Expected an error for a field with the key of an index, but got: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package parsing
+
+import (
+	"slices"
+	"strings"
+)
+
+// the purpose of this file is to match flattened keys against explode array paths, which may contain glob-style wildcards.
+//   A "*" segment (or a "*" within a segment) matches any characters within a single segment, while a "**" segment matches zero or more segments.
+//   For example, "devices.*.readings" matches "devices.battery.readings", and "devices.**" matches every array nested within devices.
+
+func isExplodePathPattern(explodePath string) bool {
+	return strings.Contains(explodePath, "*")
+}
+
+// matchesExplodePath determines if the given fullKey should be exploded given explodeDataPaths.
+// separator is the separator between the segments of keys and paths.
+func matchesExplodePath(explodeDataPaths []string, fullKey string, separator string) bool {
+	if slices.Contains(explodeDataPaths, fullKey) {
+		return true
+	}
+	var keySegments []string = nil
+	for _, explodePath := range explodeDataPaths {
+		if !isExplodePathPattern(explodePath) {
+			continue
+		}
+		if keySegments == nil {
+			keySegments = strings.Split(fullKey, separator)
+		}
+		if matchPathSegments(strings.Split(explodePath, separator), keySegments) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPathSegments(patternSegments []string, keySegments []string) bool {
+	if len(patternSegments) == 0 {
+		return len(keySegments) == 0
+	}
+	if patternSegments[0] == "**" {
+		// try to have "**" match zero segments, then one segment, then two, etc
+		for i := 0; i <= len(keySegments); i++ {
+			if matchPathSegments(patternSegments[1:], keySegments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(keySegments) == 0 || !matchSegment(patternSegments[0], keySegments[0]) {
+		return false
+	}
+	return matchPathSegments(patternSegments[1:], keySegments[1:])
+}
+
+// matchSegment matches a single segment where "*" matches any number of characters.
+func matchSegment(pattern string, segment string) bool {
+	star := strings.IndexByte(pattern, '*')
+	if star < 0 {
+		return pattern == segment
+	}
+	prefix := pattern[:star]
+	if !strings.HasPrefix(segment, prefix) {
+		return false
+	}
+	rest := pattern[star+1:]
+	for i := len(prefix); i <= len(segment); i++ {
+		if matchSegment(rest, segment[i:]) {
+			return true
+		}
+	}
+	return false
+}
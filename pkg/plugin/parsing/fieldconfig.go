@@ -0,0 +1,63 @@
+package parsing
+
+import (
+	"fmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/parsing/framemap"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+)
+
+// the purpose of this file is to convert the field configs of a querymodel.ParsingOption into settings for the framemap.FrameMap
+
+// toFieldSettings converts fieldConfig into the settings of a field, or returns a friendly error if fieldConfig is misconfigured
+func toFieldSettings(fieldConfig querymodel.FieldConfig) (framemap.FieldSettings, error) {
+	if fieldConfig.Min != nil && fieldConfig.Max != nil && *fieldConfig.Min > *fieldConfig.Max {
+		return framemap.FieldSettings{}, fmt.Errorf("the min of field: %s must not be greater than its max", fieldConfig.FieldPath)
+	}
+	config := &data.FieldConfig{
+		DisplayNameFromDS: fieldConfig.DisplayName,
+		Description:       fieldConfig.Description,
+		Unit:              fieldConfig.Unit,
+		Decimals:          fieldConfig.Decimals,
+	}
+	if fieldConfig.Min != nil {
+		config.SetMin(*fieldConfig.Min)
+	}
+	if fieldConfig.Max != nil {
+		config.SetMax(*fieldConfig.Max)
+	}
+	return framemap.FieldSettings{
+		Name:   fieldConfig.Alias,
+		Config: config,
+	}, nil
+}
+
+// setFieldSettings sets the settings of each field that has a field config or a value map that emits field mappings, or returns a friendly error
+func setFieldSettings(fm *framemap.FrameMap, parsingOption querymodel.ParsingOption) error {
+	settingsByKey := map[string]framemap.FieldSettings{}
+	for _, fieldConfig := range parsingOption.FieldConfigs {
+		if fieldConfig.FieldPath == "" {
+			continue
+		}
+		settings, err := toFieldSettings(fieldConfig)
+		if err != nil {
+			return err
+		}
+		settingsByKey[fieldConfig.FieldPath] = settings
+	}
+	for _, valueMap := range parsingOption.ValueMaps {
+		if valueMap.FieldPath == "" || !valueMap.EmitFieldMappings {
+			continue
+		}
+		settings, exists := settingsByKey[valueMap.FieldPath]
+		if !exists {
+			settings = framemap.FieldSettings{Config: &data.FieldConfig{}}
+		}
+		settings.Config.Mappings = append(settings.Config.Mappings, toGrafanaValueMappings(valueMap)...)
+		settingsByKey[valueMap.FieldPath] = settings
+	}
+	for key, settings := range settingsByKey {
+		fm.SetFieldSettings(key, settings)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package parsing
+
+import (
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"strings"
+	"testing"
+)
+
+func TestFieldConfigs(t *testing.T) {
+	decimals := uint16(2)
+	minimum := 10.0
+	maximum := 15.0
+	frames, err := parseTestData(t, `{"data": [{"packet": {"batteryVoltage": 12.5, "state": "OK"}}]}`, querymodel.ParsingOption{
+		DataPath: "data",
+		FieldConfigs: []querymodel.FieldConfig{
+			{
+				FieldPath:   "packet.batteryVoltage",
+				Alias:       "batteryVoltage",
+				DisplayName: "Battery Voltage",
+				Unit:        "volt",
+				Decimals:    &decimals,
+				Min:         &minimum,
+				Max:         &maximum,
+				Description: "The voltage of the battery",
+			},
+			{FieldPath: "packet.state", Description: "The state"},
+		},
+		FieldTypes: []querymodel.FieldTypeHint{{FieldPath: "packet.state", Type: querymodel.ENUM}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	field, _ := frames[0].FieldByName("batteryVoltage")
+	if field == nil {
+		t.Fatal("Expected the field to be renamed")
+	}
+	config := field.Config
+	if config == nil ||
+		config.DisplayNameFromDS != "Battery Voltage" ||
+		config.Unit != "volt" ||
+		*config.Decimals != 2 ||
+		*config.Min != data.ConfFloat64(10) ||
+		*config.Max != data.ConfFloat64(15) ||
+		config.Description != "The voltage of the battery" {
+		t.Errorf("Unexpected config: %+v", config)
+	}
+	state, _ := frames[0].FieldByName("packet.state")
+	if state.Config.Description != "The state" || state.Config.TypeConfig == nil || state.Config.TypeConfig.Enum == nil {
+		t.Errorf("Expected the config to be merged with the enum config: %+v", state.Config)
+	}
+
+	_, err = parseTestData(t, `{"data": [{"value": 1}]}`, querymodel.ParsingOption{
+		DataPath:     "data",
+		FieldConfigs: []querymodel.FieldConfig{{FieldPath: "value", Min: &maximum, Max: &minimum}},
+	})
+	if err == nil {
+		t.Error("Expected an error when min is greater than max")
+	}
+	_, err = parseTestData(t, `{"data": [{"packet": {"voltage": 12.5}, "voltage": 12.4}]}`, querymodel.ParsingOption{
+		DataPath:     "data",
+		FieldConfigs: []querymodel.FieldConfig{{FieldPath: "packet.voltage", Alias: "voltage"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "voltage") {
+		t.Errorf("Expected an error when an alias is the name of another field. err: %v", err)
+	}
+}
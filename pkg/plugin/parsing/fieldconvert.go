@@ -0,0 +1,198 @@
+package parsing
+
+import (
+	"fmt"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/parsing/framemap"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/util/jsonnode"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// the purpose of this file is to convert flattened values into values that can be stored in a framemap.Row
+
+// fieldConverter converts the flattened values of a parsing option's fields
+type fieldConverter struct {
+	parsingOption *querymodel.ParsingOption
+	timeParsers   map[string]*timeParser
+}
+
+// newFieldConverter creates a fieldConverter or returns a friendly error if the parsing option is misconfigured
+func newFieldConverter(parsingOption *querymodel.ParsingOption) (*fieldConverter, error) {
+	timeParsers, err := newTimeParsers(parsingOption.TimeFields)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateValueMaps(parsingOption.ValueMaps); err != nil {
+		return nil, err
+	}
+	for _, fieldTypeHint := range parsingOption.FieldTypes {
+		switch fieldTypeHint.Type {
+		case querymodel.TIME:
+			if _, isTimeField := timeParsers[fieldTypeHint.FieldPath]; !isTimeField {
+				timeParsers[fieldTypeHint.FieldPath], _ = newTimeParser(querymodel.TimeField{TimePath: fieldTypeHint.FieldPath})
+			}
+		case querymodel.STRING, querymodel.NUMBER, querymodel.INT, querymodel.BOOL, querymodel.JSON, querymodel.ENUM:
+		default:
+			return nil, fmt.Errorf("unknown type: %s for field: %s", fieldTypeHint.Type, fieldTypeHint.FieldPath)
+		}
+	}
+	return &fieldConverter{
+		parsingOption: parsingOption,
+		timeParsers:   timeParsers,
+	}, nil
+}
+
+// convert converts the value of the field at key. Values are mapped using the value map of the field before anything else.
+// A type hint takes precedence over time fields, which take precedence over number options.
+// A FRIENDLY_ERROR does not include the row, so the caller should add it.
+func (c *fieldConverter) convert(key string, value jsonnode.Node) (any, ParseDataErrorType, error) {
+	if valueMap := c.parsingOption.GetValueMap(key); valueMap != nil {
+		value = mapValue(valueMap, value)
+	}
+	fieldTypeHint := c.parsingOption.GetFieldTypeHint(key)
+	if fieldTypeHint != nil && fieldTypeHint.Type != querymodel.TIME {
+		converted, err := coerceValue(key, value, fieldTypeHint.Type, c.parsingOption.GetNumberType(key), c.parsingOption.GetNumberField(key))
+		if err != nil {
+			return nil, FRIENDLY_ERROR, err
+		}
+		return converted, NO_ERROR, nil
+	}
+	if timeParser, isTimeField := c.timeParsers[key]; isTimeField {
+		timePointer, err := timeParser.parse(value)
+		if err != nil {
+			return nil, FRIENDLY_ERROR, err
+		}
+		if timePointer == nil {
+			return jsonnode.NULL, NO_ERROR, nil
+		}
+		return *timePointer, NO_ERROR, nil
+	}
+	if numberField := c.parsingOption.GetNumberField(key); numberField != nil && numberField.ParseStrings {
+		if typedValue, isString := value.(jsonnode.String); isString {
+			var err error
+			value, err = parseNumericString(key, typedValue)
+			if err != nil {
+				return nil, FRIENDLY_ERROR, err
+			}
+		}
+	}
+	switch typedValue := value.(type) {
+	case jsonnode.String:
+		return typedValue.String(), NO_ERROR, nil
+	case jsonnode.Boolean:
+		return typedValue.Bool(), NO_ERROR, nil
+	case jsonnode.Number:
+		// NOTE: When the number type is AUTO, the jsonnode.Number is stored directly into the FieldMap (it's part of the contract to support that).
+		//   The resulting field is always numeric, which is required for alerting queries
+		scaledValue, err := scaleNumber(key, typedValue, c.parsingOption.GetNumberField(key))
+		if err != nil {
+			return nil, FRIENDLY_ERROR, err
+		}
+		parsedValue, err := convertNumber(key, scaledValue, c.parsingOption.GetNumberType(key))
+		if err != nil {
+			return nil, FRIENDLY_ERROR, err
+		}
+		return parsedValue, NO_ERROR, nil
+	case jsonnode.Null:
+		return typedValue, NO_ERROR, nil
+	case *jsonnode.Object, *jsonnode.Array:
+		// Nested objects and arrays are only present when they are kept as JSON rather than flattened
+		return typedValue.Serialize(), NO_ERROR, nil
+	}
+	return nil, UNKNOWN_ERROR, fmt.Errorf("unsupported type! type: %v", reflect.TypeOf(value))
+}
+
+// coerceValue converts value to the given field type. Null values always remain null. Numbers are scaled using numberField, which may be nil.
+// Any error returned is a friendly error.
+func coerceValue(key string, value jsonnode.Node, fieldType querymodel.FieldType, numberType querymodel.NumberType, numberField *querymodel.NumberField) (any, error) {
+	if _, isNull := value.(jsonnode.Null); isNull {
+		return jsonnode.NULL, nil
+	}
+	switch fieldType {
+	case querymodel.STRING:
+		if typedValue, isString := value.(jsonnode.String); isString {
+			return typedValue.String(), nil
+		}
+		return string(value.Serialize()), nil
+	case querymodel.JSON:
+		return value.Serialize(), nil
+	case querymodel.ENUM:
+		if typedValue, isString := value.(jsonnode.String); isString {
+			return framemap.EnumValue(typedValue.String()), nil
+		}
+		return framemap.EnumValue(value.Serialize()), nil
+	case querymodel.BOOL:
+		switch typedValue := value.(type) {
+		case jsonnode.Boolean:
+			return typedValue.Bool(), nil
+		case jsonnode.Number:
+			number, err := typedValue.Float64()
+			if err != nil {
+				return nil, fmt.Errorf("could not convert value: %s of field: %s to a boolean", value, key)
+			}
+			return number != 0, nil
+		case jsonnode.String:
+			switch strings.ToLower(strings.TrimSpace(typedValue.String())) {
+			case "true", "t", "yes", "y", "on", "1":
+				return true, nil
+			case "false", "f", "no", "n", "off", "0":
+				return false, nil
+			case "":
+				return jsonnode.NULL, nil
+			}
+		}
+		return nil, fmt.Errorf("could not convert value: %s of field: %s to a boolean", value, key)
+	case querymodel.NUMBER, querymodel.INT:
+		number, err := coerceToNumber(key, value)
+		if err != nil || number == nil {
+			return jsonnode.NULL, err
+		}
+		scaledNumber, err := scaleNumber(key, *number, numberField)
+		if err != nil {
+			return nil, err
+		}
+		if fieldType == querymodel.INT {
+			return coerceToInt(key, scaledNumber)
+		}
+		return convertNumber(key, scaledNumber, numberType)
+	}
+	return nil, fmt.Errorf("unknown type: %s for field: %s", fieldType, key)
+}
+
+// coerceToNumber converts booleans, numbers and numeric strings to a number. A nil number is returned for blank strings.
+func coerceToNumber(key string, value jsonnode.Node) (*jsonnode.Number, error) {
+	switch typedValue := value.(type) {
+	case jsonnode.Number:
+		return &typedValue, nil
+	case jsonnode.Boolean:
+		number := jsonnode.Number("0")
+		if typedValue.Bool() {
+			number = "1"
+		}
+		return &number, nil
+	case jsonnode.String:
+		node, err := parseNumericString(key, typedValue)
+		if err != nil {
+			return nil, err
+		}
+		if number, isNumber := node.(jsonnode.Number); isNumber {
+			return &number, nil
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("could not convert value: %s of field: %s to a number", value, key)
+}
+
+// coerceToInt converts a number to an int64. Numbers such as 5.0 are allowed, but numbers with a fractional part are not.
+func coerceToInt(key string, number jsonnode.Number) (int64, error) {
+	if value, err := number.Int64(); err == nil {
+		return value, nil
+	}
+	floatValue, err := strconv.ParseFloat(number.String(), 64)
+	if err == nil && floatValue == float64(int64(floatValue)) && floatValue >= -(1<<63) && floatValue < 1<<63 {
+		return int64(floatValue), nil
+	}
+	return 0, fmt.Errorf("could not convert value: %s of field: %s to an integer", number, key)
+}
@@ -0,0 +1,89 @@
+package parsing
+
+import (
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFieldTypeHints(t *testing.T) {
+	jsonString := `
+{
+  "data": [
+    { "id": 5, "count": "12", "ratio": 3.0, "enabled": "yes", "createdAt": "2024-01-04T02:00:00Z", "status": "OK", "extra": 1.5 },
+    { "id": 6, "count": "", "ratio": 4, "enabled": 0, "createdAt": null, "status": "FAILED", "extra": "a" },
+    { "id": 7, "count": true, "ratio": null, "enabled": true, "createdAt": "2024-01-04T03:00:00Z", "status": "OK", "extra": null }
+  ]
+}
+`
+	frames, err := parseTestData(t, jsonString, querymodel.ParsingOption{
+		DataPath: "data",
+		FieldTypes: []querymodel.FieldTypeHint{
+			{FieldPath: "id", Type: querymodel.STRING},
+			{FieldPath: "count", Type: querymodel.NUMBER},
+			{FieldPath: "ratio", Type: querymodel.INT},
+			{FieldPath: "enabled", Type: querymodel.BOOL},
+			{FieldPath: "createdAt", Type: querymodel.TIME},
+			{FieldPath: "status", Type: querymodel.ENUM},
+			{FieldPath: "extra", Type: querymodel.JSON},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := frames[0]
+	for fieldName, expectedType := range map[string]data.FieldType{
+		"id":        data.FieldTypeNullableString,
+		"count":     data.FieldTypeNullableFloat64,
+		"ratio":     data.FieldTypeNullableInt64,
+		"enabled":   data.FieldTypeNullableBool,
+		"createdAt": data.FieldTypeNullableTime,
+		"status":    data.FieldTypeNullableEnum,
+		"extra":     data.FieldTypeNullableJSON,
+	} {
+		field, _ := frame.FieldByName(fieldName)
+		if field == nil || field.Type() != expectedType {
+			t.Errorf("Expected field %s to have type %v", fieldName, expectedType)
+		}
+	}
+	expectedValues := map[string][]any{
+		"id":        {"5", "6", "7"},
+		"count":     {12.0, nil, 1.0},
+		"ratio":     {int64(3), int64(4), nil},
+		"enabled":   {true, false, true},
+		"createdAt": {time.Date(2024, 1, 4, 2, 0, 0, 0, time.UTC), nil, time.Date(2024, 1, 4, 3, 0, 0, 0, time.UTC)},
+		"status":    {data.EnumItemIndex(0), data.EnumItemIndex(1), data.EnumItemIndex(0)},
+		"extra":     {"1.5", `"a"`, "null"},
+	}
+	assertFieldValues(t, frame, expectedValues)
+	status, _ := frame.FieldByName("status")
+	if status.Config == nil || strings.Join(status.Config.TypeConfig.Enum.Text, ",") != "OK,FAILED" {
+		t.Errorf("Unexpected enum config: %v", status.Config)
+	}
+}
+
+func TestFieldTypeHintErrors(t *testing.T) {
+	_, err := parseTestData(t, `{"data": [{"ratio": 1}, {"ratio": 1.5}]}`, querymodel.ParsingOption{
+		DataPath:   "data",
+		FieldTypes: []querymodel.FieldTypeHint{{FieldPath: "ratio", Type: querymodel.INT}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "row 1") {
+		t.Errorf("Expected an error that names the row, but got: %v", err)
+	}
+	_, err = parseTestData(t, `{"data": [{"enabled": "maybe"}]}`, querymodel.ParsingOption{
+		DataPath:   "data",
+		FieldTypes: []querymodel.FieldTypeHint{{FieldPath: "enabled", Type: querymodel.BOOL}},
+	})
+	if err == nil {
+		t.Error("Expected an error when a string is not a boolean")
+	}
+	_, err = parseTestData(t, `{"data": []}`, querymodel.ParsingOption{
+		DataPath:   "data",
+		FieldTypes: []querymodel.FieldTypeHint{{FieldPath: "a", Type: "complex"}},
+	})
+	if err == nil {
+		t.Error("Expected an error for an unknown type")
+	}
+}
@@ -0,0 +1,90 @@
+package parsing
+
+import (
+	"fmt"
+	"github.com/emirpasic/gods/v2/sets"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"regexp"
+	"strings"
+)
+
+// the purpose of this file is to decide which flattened keys are included in the data frame
+
+// fieldPattern matches flattened keys using either a glob (see matchesExplodePath) or a regular expression
+type fieldPattern struct {
+	glob string
+	// regex is not nil when the pattern was written as /regex/
+	regex *regexp.Regexp
+}
+
+// newFieldPattern parses a pattern or returns a friendly error. Patterns surrounded by "/" are regular expressions, and all other patterns are globs.
+func newFieldPattern(pattern string) (fieldPattern, error) {
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		regex, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return fieldPattern{}, fmt.Errorf("invalid regular expression: %s error: %w", pattern, err)
+		}
+		return fieldPattern{regex: regex}, nil
+	}
+	return fieldPattern{glob: pattern}, nil
+}
+
+func (pattern fieldPattern) matches(key string, separator string) bool {
+	if pattern.regex != nil {
+		return pattern.regex.MatchString(key)
+	}
+	return matchesExplodePath([]string{pattern.glob}, key, separator)
+}
+
+type fieldFilter struct {
+	// fieldsExcludedFromDataFrame are the fields excluded by label options
+	fieldsExcludedFromDataFrame sets.Set[string]
+	// include is empty when every field is included
+	include   []fieldPattern
+	exclude   []fieldPattern
+	separator string
+}
+
+// newFieldFilter creates a fieldFilter or returns a friendly error if the include or exclude patterns of parsingOption are invalid
+func newFieldFilter(parsingOption querymodel.ParsingOption, separator string) (*fieldFilter, error) {
+	filter := &fieldFilter{
+		fieldsExcludedFromDataFrame: parsingOption.GetFieldsExcludedFromDataFrame(),
+		separator:                   separator,
+	}
+	for _, pattern := range parsingOption.IncludeFields {
+		parsedPattern, err := newFieldPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		filter.include = append(filter.include, parsedPattern)
+	}
+	for _, pattern := range parsingOption.ExcludeFields {
+		parsedPattern, err := newFieldPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		filter.exclude = append(filter.exclude, parsedPattern)
+	}
+	return filter, nil
+}
+
+// includes determines if the field at key should be included in the data frame.
+// When there are include patterns, key must match one of them. key must not match any exclude pattern.
+func (filter *fieldFilter) includes(key string) bool {
+	if filter.fieldsExcludedFromDataFrame.Contains(key) {
+		return false
+	}
+	if len(filter.include) > 0 && !filter.matchesAny(filter.include, key) {
+		return false
+	}
+	return !filter.matchesAny(filter.exclude, key)
+}
+
+func (filter *fieldFilter) matchesAny(patterns []fieldPattern, key string) bool {
+	for _, pattern := range patterns {
+		if pattern.matches(key, filter.separator) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package parsing
+
+import (
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"testing"
+)
+
+func TestFieldFilter(t *testing.T) {
+	jsonString := `
+{
+  "data": [
+    { "time": 1, "host": "a", "value": 1.5, "valueMax": 2.5, "details": { "cpu": 1, "memory": 2, "internal": { "id": 5 } } }
+  ]
+}
+`
+	for _, test := range []struct {
+		includeFields  []string
+		excludeFields  []string
+		expectedFields []string
+	}{
+		{nil, nil, []string{"time", "host", "value", "valueMax", "details.cpu", "details.memory", "details.internal.id"}},
+		{[]string{"time", "value*"}, nil, []string{"time", "value", "valueMax"}},
+		{nil, []string{"details.**"}, []string{"time", "host", "value", "valueMax"}},
+		{[]string{"/^(time|details)/"}, []string{"details.internal.*"}, []string{"time", "details.cpu", "details.memory"}},
+	} {
+		frames, err := parseTestData(t, jsonString, querymodel.ParsingOption{
+			DataPath:      "data",
+			IncludeFields: test.includeFields,
+			ExcludeFields: test.excludeFields,
+			LabelOptions:  []querymodel.LabelOption{{Name: "host", Type: querymodel.FIELD, Value: "host"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		frame := frames[0]
+		var names []string
+		for _, field := range frame.Fields {
+			names = append(names, field.Name)
+		}
+		if len(names) != len(test.expectedFields) {
+			t.Errorf("Expected fields %v but got %v", test.expectedFields, names)
+			continue
+		}
+		for i := range names {
+			if names[i] != test.expectedFields[i] {
+				t.Errorf("Expected fields %v but got %v", test.expectedFields, names)
+				break
+			}
+		}
+		if frame.Fields[0].Labels["host"] != "a" {
+			t.Errorf("Expected excluded fields to still be usable by labels. labels: %v", frame.Fields[0].Labels)
+		}
+	}
+
+	_, err := parseTestData(t, jsonString, querymodel.ParsingOption{DataPath: "data", IncludeFields: []string{"/(/"}})
+	if err == nil {
+		t.Error("Expected an error for an invalid regular expression")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/parsing/fieldsort"
 	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/util/jsonnode"
+	"math"
 	"reflect"
 	"time"
 )
@@ -45,13 +46,83 @@ func createFieldForNativeType[T comparable](node *frameNode, field string) *data
 	}
 	return data.NewField(field, node.labels, values)
 }
+
+// numberTypeOf determines the type used for a field containing jsonnode.Number values.
+// int64 is used if every value is an integer that fits, then uint64, and float64 is used otherwise.
+// All frame nodes are considered so that the type of a field is consistent between the data.Frames we return.
+// The result is cached, so this should only be called once all rows have been added.
+func (f *FrameMap) numberTypeOf(field string) reflect.Type {
+	if numberType, cached := f.numberTypes[field]; cached {
+		return numberType
+	}
+	numberType := f.computeNumberTypeOf(field)
+	if f.numberTypes == nil {
+		f.numberTypes = map[string]reflect.Type{}
+	}
+	f.numberTypes[field] = numberType
+	return numberType
+}
+
+func (f *FrameMap) computeNumberTypeOf(field string) reflect.Type {
+	fitsInt64 := true
+	fitsUint64 := true
+	frameMapIterator := f.data.Iterator()
+	for frameMapIterator.Next() {
+		for _, row := range frameMapIterator.Value().rows {
+			number, isNumber := row.FieldMap[field].(jsonnode.Number)
+			if !isNumber {
+				continue
+			}
+			if fitsInt64 {
+				if _, err := number.Int64(); err != nil {
+					fitsInt64 = false
+				}
+			}
+			if fitsUint64 {
+				if _, err := number.Uint64(); err != nil {
+					fitsUint64 = false
+				}
+			}
+		}
+	}
+	if fitsInt64 {
+		return reflect.TypeOf(int64(0))
+	} else if fitsUint64 {
+		return reflect.TypeOf(uint64(0))
+	}
+	return reflect.TypeOf(float64(0))
+}
+
+// createFieldForNumbers creates a field of the given numeric type from jsonnode.Number values
+func createFieldForNumbers[T int64 | uint64 | float64](node *frameNode, field string, convert func(jsonnode.Number) (T, error)) (*data.Field, error) {
+	var values []*T
+	for rowIndex, row := range node.rows {
+		switch rawValue := row.FieldMap[field].(type) {
+		case jsonnode.Number:
+			value, err := convert(rawValue)
+			if err != nil {
+				return nil, fmt.Errorf("field %s of row %d could not be converted to a number: %w", field, rowIndex, err)
+			}
+			values = append(values, &value)
+		default:
+			values = append(values, nil)
+		}
+	}
+	return data.NewField(field, node.labels, values), nil
+}
+
 func createFieldForJsonNode(node *frameNode, field string) *data.Field {
 	var values []*json.RawMessage
 	for _, row := range node.rows {
 		rawValue, exists := row.FieldMap[field]
 		if exists {
-			value := rawValue.(jsonnode.Node)
-			serializedValue := value.Serialize()
+			var serializedValue json.RawMessage
+			switch typedValue := rawValue.(type) {
+			case json.RawMessage:
+				serializedValue = typedValue
+			default:
+				serializedValue = rawValue.(jsonnode.Node).Serialize()
+			}
 			values = append(values, &serializedValue)
 		} else {
 			values = append(values, nil)
@@ -60,10 +131,94 @@ func createFieldForJsonNode(node *frameNode, field string) *data.Field {
 	return data.NewField(field, node.labels, values)
 }
 
+// enumValues holds the distinct values of an enum field, in the order they were first seen
+type enumValues struct {
+	texts   []string
+	indexes map[EnumValue]data.EnumItemIndex
+}
+
+// enumValuesOf returns the distinct values of an enum field across all frame nodes, or nil if there are too many distinct values to be represented as an enum.
+// The result is cached, so this should only be called once all rows have been added.
+func (f *FrameMap) enumValuesOf(field string) *enumValues {
+	if values, cached := f.enums[field]; cached {
+		return values
+	}
+	values := &enumValues{
+		indexes: map[EnumValue]data.EnumItemIndex{},
+	}
+	frameMapIterator := f.data.Iterator()
+	for frameMapIterator.Next() && values != nil {
+		for _, row := range frameMapIterator.Value().rows {
+			value, isEnum := row.FieldMap[field].(EnumValue)
+			if !isEnum {
+				continue
+			}
+			if _, seen := values.indexes[value]; !seen {
+				if len(values.texts) > math.MaxUint16 {
+					values = nil
+					break
+				}
+				values.indexes[value] = data.EnumItemIndex(len(values.texts))
+				values.texts = append(values.texts, string(value))
+			}
+		}
+	}
+	if f.enums == nil {
+		f.enums = map[string]*enumValues{}
+	}
+	f.enums[field] = values
+	return values
+}
+
+func (f *FrameMap) createFieldForEnum(node *frameNode, field string) *data.Field {
+	enum := f.enumValuesOf(field)
+	if enum == nil {
+		// Too many distinct values to be represented as an enum, so we fall back to a string field
+		var values []*string
+		for _, row := range node.rows {
+			if value, isEnum := row.FieldMap[field].(EnumValue); isEnum {
+				stringValue := string(value)
+				values = append(values, &stringValue)
+			} else {
+				values = append(values, nil)
+			}
+		}
+		return data.NewField(field, node.labels, values)
+	}
+	var values []*data.EnumItemIndex
+	for _, row := range node.rows {
+		if value, isEnum := row.FieldMap[field].(EnumValue); isEnum {
+			index := enum.indexes[value]
+			values = append(values, &index)
+		} else {
+			values = append(values, nil)
+		}
+	}
+	dataField := data.NewField(field, node.labels, values)
+	dataField.Config = &data.FieldConfig{
+		TypeConfig: &data.FieldTypeConfig{
+			Enum: &data.EnumFieldConfig{
+				Text: enum.texts,
+			},
+		},
+	}
+	return dataField
+}
+
 // Creates a field given a frameNode and a field key
-// If an error is returned, it is unexpected and is the result of an error within the plugin itself.
+// If an error other than a *MixedTypeError is returned, it is unexpected and is the result of an error within the plugin itself.
 // No possible configuration done by the user should cause this to return an error
 func (f *FrameMap) createField(targetNode *frameNode, fieldKey string) (*data.Field, error) {
+	if mixedTypeError := f.mixedTypeErrorOf(fieldKey); mixedTypeError != nil {
+		if f.mixedTypeHandling == MIXED_TYPES_AS_ERROR {
+			return nil, mixedTypeError
+		}
+		return createFieldForMixedTypes(targetNode, fieldKey, f.mixedTypeHandling)
+	}
+	if f.isDerivedField(fieldKey) {
+		// Derived values are float64 or null. Even when every value is null, the field is numeric so that alert rules get numeric frames
+		return createFieldForNativeType[float64](targetNode, fieldKey), nil
+	}
 	var foundNull = false
 
 	// Although we don't have to iterate over all the nodes within a FrameMap,
@@ -77,7 +232,13 @@ func (f *FrameMap) createField(targetNode *frameNode, fieldKey string) (*data.Fi
 				case jsonnode.Null:
 					foundNull = true
 				case jsonnode.Number:
-					return createFieldForJsonNode(targetNode, fieldKey), nil
+					switch f.numberTypeOf(fieldKey) {
+					case reflect.TypeOf(int64(0)):
+						return createFieldForNumbers(targetNode, fieldKey, jsonnode.Number.Int64)
+					case reflect.TypeOf(uint64(0)):
+						return createFieldForNumbers(targetNode, fieldKey, jsonnode.Number.Uint64)
+					}
+					return createFieldForNumbers(targetNode, fieldKey, jsonnode.Number.Float64)
 				case time.Time:
 					return createFieldForNativeType[time.Time](targetNode, fieldKey), nil
 				case string:
@@ -86,6 +247,14 @@ func (f *FrameMap) createField(targetNode *frameNode, fieldKey string) (*data.Fi
 					return createFieldForNativeType[bool](targetNode, fieldKey), nil
 				case float64:
 					return createFieldForNativeType[float64](targetNode, fieldKey), nil
+				case int64:
+					return createFieldForNativeType[int64](targetNode, fieldKey), nil
+				case uint64:
+					return createFieldForNativeType[uint64](targetNode, fieldKey), nil
+				case json.RawMessage:
+					return createFieldForJsonNode(targetNode, fieldKey), nil
+				case EnumValue:
+					return f.createFieldForEnum(targetNode, fieldKey), nil
 				default:
 					return nil, fmt.Errorf("field %s of row %d has unknown type: %v", fieldKey, rowIndex, reflect.TypeOf(value))
 				}
@@ -100,7 +269,7 @@ func (f *FrameMap) createField(targetNode *frameNode, fieldKey string) (*data.Fi
 }
 
 // ToFrames transforms the FrameMap to an array of frames
-// Any error that is returned is not caused by the user, and is an unexpected error.
+// Any error that is returned other than a *MixedTypeError or a *FieldNameCollisionError is not caused by the user, and is an unexpected error.
 func (f *FrameMap) ToFrames() ([]*data.Frame, error) {
 	// create data frame response.
 	// For an overview on data frames and how grafana handles them:
@@ -110,13 +279,27 @@ func (f *FrameMap) ToFrames() ([]*data.Frame, error) {
 
 	// NOTE: The order of the frames here determines the order they appear in the legend in Grafana
 	//   This is why we use a linkedhashmap.Map everywhere, as it maintains order.
+	f.orderRows()
+	f.computeDerivedFields()
+	f.limitSeries()
+	f.downsample()
+	f.limitRows()
+
 	var r []*data.Frame
 	fields := f.getAllFields()
+	if err := f.checkFieldNames(fields); err != nil {
+		return nil, err
+	}
 	frameMapIterator := f.data.Iterator()
 	for frameMapIterator.Next() {
 		node := frameMapIterator.Value()
 
 		frameName := fmt.Sprintf("response %v", node.labels)
+		if f.nameFunc != nil {
+			if name := f.nameFunc(node.labels, ""); name != "" {
+				frameName = name
+			}
+		}
 		frame := data.NewFrame(frameName)
 
 		for _, fieldKey := range fields {
@@ -124,6 +307,8 @@ func (f *FrameMap) ToFrames() ([]*data.Frame, error) {
 			if err != nil {
 				return nil, err
 			}
+			f.applyFieldSettings(fieldKey, field)
+			f.applyDisplayName(node.labels, field)
 			frame.Fields = append(frame.Fields, field)
 		}
 		r = append(r, frame)
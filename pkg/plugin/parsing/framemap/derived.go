@@ -0,0 +1,176 @@
+package framemap
+
+import (
+	"fmt"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/util/jsonnode"
+	"math"
+	"slices"
+	"sort"
+	"time"
+)
+
+// the purpose of this file is to derive fields such as rates from counters, which are computed separately for each frame
+
+type DerivedFieldType int
+
+const (
+	// DERIVE_DELTA is the difference between a value and the previous value
+	DERIVE_DELTA DerivedFieldType = iota
+	// DERIVE_RATE is the difference between a value and the previous value per second
+	DERIVE_RATE
+	// DERIVE_NON_NEGATIVE_DERIVATIVE is the same as DERIVE_RATE, but negative values are null
+	DERIVE_NON_NEGATIVE_DERIVATIVE
+)
+
+type DerivedField struct {
+	// Name is the key of the derived field
+	Name string
+	// Field is the key of the field values are derived from
+	Field string
+	// TimeField is the key of the time field that rows are sorted by, or blank to use the order rows were created.
+	//   A time field is required for DERIVE_RATE and DERIVE_NON_NEGATIVE_DERIVATIVE
+	TimeField string
+	Type      DerivedFieldType
+	// When true, a value that is less than the previous value is treated as a counter that was reset to zero,
+	//   so the difference is the value itself rather than a negative number
+	CounterResets bool
+}
+
+// AddDerivedField adds a field that is derived from another field when ToFrames is called. Derived fields are always nullable float64 fields.
+// Derived fields are computed in the order they are added, so a derived field may be derived from the derived fields before it.
+// Derived fields should be added after every row. An error is returned if the name of derivedField is already the key of a field or of another derived field,
+// because the derived values would replace the values of that field, or if the field it is derived from does not exist in any row.
+func (f *FrameMap) AddDerivedField(derivedField DerivedField) error {
+	if f.isDerivedField(derivedField.Name) {
+		return fmt.Errorf("derived field: %s has the same name as another derived field", derivedField.Name)
+	}
+	fields := f.getAllFields()
+	if slices.Contains(fields, derivedField.Name) {
+		return fmt.Errorf("derived field: %s has the same name as an existing field", derivedField.Name)
+	}
+	// When there are no rows at all, such as when the response is empty, there is nothing to derive, which is not an error
+	if len(fields) > 0 && !f.isDerivedField(derivedField.Field) && !slices.Contains(fields, derivedField.Field) {
+		return fmt.Errorf("derived field: %s is derived from field: %s, which does not exist in any row", derivedField.Name, derivedField.Field)
+	}
+	f.derivedFields = append(f.derivedFields, derivedField)
+	return nil
+}
+
+// toFloat64 converts a numeric value stored in a Row to a float64. ok is false if value is not numeric.
+func toFloat64(value any) (number float64, ok bool) {
+	switch typedValue := value.(type) {
+	case jsonnode.Number:
+		number, err := typedValue.Float64()
+		return number, err == nil
+	case float64:
+		return typedValue, true
+	case int64:
+		return float64(typedValue), true
+	case uint64:
+		return float64(typedValue), true
+	}
+	return 0, false
+}
+
+// computeDerivedFields computes each derived field for each frame node.
+// Derived fields are ordered after the field they are derived from, in the order they were added.
+func (f *FrameMap) computeDerivedFields() {
+	// The field each derived field is ordered after
+	previousFields := map[string]string{}
+	for _, derivedField := range f.derivedFields {
+		previousField, exists := previousFields[derivedField.Field]
+		if !exists {
+			previousField = derivedField.Field
+		}
+		previousFields[derivedField.Field] = derivedField.Name
+		frameMapIterator := f.data.Iterator()
+		for frameMapIterator.Next() {
+			computeDerivedField(frameMapIterator.Value(), derivedField, previousField)
+		}
+	}
+}
+
+// computeDerivedField computes derivedField for the rows of node. Rows whose value or time is missing or not valid have a null derived value.
+// The derived field is ordered after previousField.
+func computeDerivedField(node *frameNode, derivedField DerivedField, previousField string) {
+	requiresTime := derivedField.Type != DERIVE_DELTA
+	rows := slices.Clone(node.rows)
+	if derivedField.TimeField != "" {
+		// The order of the rows in the frame is not changed, only the order used to compute the derived values
+		sortRowsByTime(rows, derivedField.TimeField)
+	} else if requiresTime {
+		for _, row := range rows {
+			putDerivedValue(row, derivedField, previousField, jsonnode.NULL)
+		}
+		return
+	}
+
+	var previousValue float64
+	var previousTime time.Time
+	hasPrevious := false
+	for _, row := range rows {
+		value, isNumber := toFloat64(row.FieldMap[derivedField.Field])
+		rowTime, hasTime := row.FieldMap[derivedField.TimeField].(time.Time)
+		if !isNumber || (derivedField.TimeField != "" && !hasTime) {
+			putDerivedValue(row, derivedField, previousField, jsonnode.NULL)
+			continue
+		}
+		if !hasPrevious {
+			putDerivedValue(row, derivedField, previousField, jsonnode.NULL)
+			previousValue, previousTime, hasPrevious = value, rowTime, true
+			continue
+		}
+		seconds := rowTime.Sub(previousTime).Seconds()
+		if requiresTime && seconds <= 0 {
+			// The same timestamp as the previous row, so there is no rate
+			putDerivedValue(row, derivedField, previousField, jsonnode.NULL)
+			continue
+		}
+		delta := value - previousValue
+		if derivedField.CounterResets && delta < 0 {
+			delta = value
+		}
+		previousValue, previousTime = value, rowTime
+
+		derivedValue := delta
+		if requiresTime {
+			derivedValue = delta / seconds
+		}
+		if math.IsNaN(derivedValue) || math.IsInf(derivedValue, 0) || (derivedField.Type == DERIVE_NON_NEGATIVE_DERIVATIVE && derivedValue < 0) {
+			putDerivedValue(row, derivedField, previousField, jsonnode.NULL)
+			continue
+		}
+		putDerivedValue(row, derivedField, previousField, derivedValue)
+	}
+}
+
+// sortRowsByTime sorts rows by the time at timeField. Rows without a time are sorted last. The sort is stable.
+func sortRowsByTime(rows []*Row, timeField string) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		timeI, okI := rows[i].FieldMap[timeField].(time.Time)
+		timeJ, okJ := rows[j].FieldMap[timeField].(time.Time)
+		if !okI || !okJ {
+			return okI && !okJ
+		}
+		return timeI.Before(timeJ)
+	})
+}
+
+// putDerivedValue puts value into row. The derived field is ordered directly after previousField, if the row has that field.
+func putDerivedValue(row *Row, derivedField DerivedField, previousField string, value any) {
+	if _, exists := row.FieldMap[derivedField.Name]; !exists {
+		if index := slices.Index(row.FieldOrder, previousField); index >= 0 {
+			row.FieldOrder = slices.Insert(row.FieldOrder, index+1, derivedField.Name)
+		} else {
+			row.FieldOrder = append(row.FieldOrder, derivedField.Name)
+		}
+	}
+	row.FieldMap[derivedField.Name] = value
+}
+
+// isDerivedField determines if key is the name of a derived field
+func (f *FrameMap) isDerivedField(key string) bool {
+	return slices.ContainsFunc(f.derivedFields, func(derivedField DerivedField) bool {
+		return derivedField.Name == key
+	})
+}
@@ -0,0 +1,295 @@
+package framemap
+
+import (
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/util/jsonnode"
+	"math"
+	"strconv"
+	"time"
+)
+
+// the purpose of this file is to reduce the number of rows of frames that have more rows than can be displayed
+
+type DownsampleMethod int
+
+const (
+	// DOWNSAMPLE_LTTB keeps the rows chosen by the Largest-Triangle-Three-Buckets algorithm, which preserves the shape of the value field
+	DOWNSAMPLE_LTTB DownsampleMethod = iota
+	// DOWNSAMPLE_MEAN replaces the rows of each bucket with a single row containing the mean of each numeric field
+	DOWNSAMPLE_MEAN
+	// DOWNSAMPLE_MIN_MAX keeps the rows with the minimum and maximum value field of each bucket
+	DOWNSAMPLE_MIN_MAX
+	// DOWNSAMPLE_LAST keeps the last row of each bucket
+	DOWNSAMPLE_LAST
+)
+
+type Downsampling struct {
+	Method DownsampleMethod
+	// TimeField is the key of the time field that rows are bucketed by
+	TimeField string
+	// ValueField is the key of the field used by DOWNSAMPLE_LTTB and DOWNSAMPLE_MIN_MAX to choose rows. A blank value uses the first numeric field
+	ValueField string
+	// MaxPoints is the maximum number of rows of a frame. Frames with fewer rows are not downsampled
+	MaxPoints int
+	// Interval is the minimum width of buckets, or 0 to use a millisecond. Buckets are aligned to multiples of Interval since the Unix epoch,
+	//   and are widened to a multiple of Interval when needed so that there are at most MaxPoints rows
+	Interval time.Duration
+}
+
+// SetDownsampling sets how frames with too many rows are downsampled by ToFrames, or nil to disable downsampling
+func (f *FrameMap) SetDownsampling(downsampling *Downsampling) {
+	f.downsampling = downsampling
+}
+
+// downsample downsamples each frame node with more than MaxPoints rows. Rows without a time are removed from downsampled frame nodes.
+func (f *FrameMap) downsample() {
+	downsampling := f.downsampling
+	if downsampling == nil || downsampling.MaxPoints <= 0 {
+		return
+	}
+	valueField := downsampling.ValueField
+	if valueField == "" {
+		valueField = f.firstNumericField(downsampling.TimeField)
+	}
+	frameMapIterator := f.data.Iterator()
+	for frameMapIterator.Next() {
+		node := frameMapIterator.Value()
+		if len(node.rows) <= downsampling.MaxPoints {
+			continue
+		}
+		var rows []*Row
+		for _, row := range node.rows {
+			if _, hasTime := row.FieldMap[downsampling.TimeField].(time.Time); hasTime {
+				rows = append(rows, row)
+			}
+		}
+		sortRowsByTime(rows, downsampling.TimeField)
+		if downsampling.Method == DOWNSAMPLE_LTTB {
+			node.rows = lttb(rows, downsampling.TimeField, valueField, downsampling.MaxPoints)
+		} else {
+			node.rows = downsampleBuckets(rows, *downsampling, valueField)
+		}
+		// Downsampled rows are sorted by time, so they are sorted again in case a different order was requested
+		f.sortRows(node)
+	}
+}
+
+// firstNumericField returns the first field other than timeField that has a numeric value, or a blank string if there is no such field
+func (f *FrameMap) firstNumericField(timeField string) string {
+	for _, field := range f.getAllFields() {
+		if field == timeField {
+			continue
+		}
+		frameMapIterator := f.data.Iterator()
+		for frameMapIterator.Next() {
+			for _, row := range frameMapIterator.Value().rows {
+				if _, isNumber := toFloat64(row.FieldMap[field]); isNumber {
+					return field
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// lttb downsamples rows sorted by time to threshold rows using the Largest-Triangle-Three-Buckets algorithm.
+// Rows whose value field is not numeric are never chosen, other than the first and last rows. threshold must be positive.
+func lttb(rows []*Row, timeField string, valueField string, threshold int) []*Row {
+	if len(rows) <= threshold {
+		return rows
+	}
+	if threshold == 1 {
+		return rows[:1]
+	}
+	if threshold == 2 {
+		return []*Row{rows[0], rows[len(rows)-1]}
+	}
+	start := rows[0].FieldMap[timeField].(time.Time)
+	x := make([]float64, len(rows))
+	y := make([]float64, len(rows))
+	// anchorY is the value of the first point of the triangle. It starts as the first numeric value, because the value of the first row may not be numeric
+	anchorY := math.NaN()
+	for i, row := range rows {
+		x[i] = row.FieldMap[timeField].(time.Time).Sub(start).Seconds()
+		value, isNumber := toFloat64(row.FieldMap[valueField])
+		if !isNumber {
+			value = math.NaN()
+		} else if math.IsNaN(anchorY) {
+			anchorY = value
+		}
+		y[i] = value
+	}
+
+	sampled := []*Row{rows[0]}
+	bucketSize := float64(len(rows)-2) / float64(threshold-2)
+	previous := 0
+	for bucket := 0; bucket < threshold-2; bucket++ {
+		// The average point of the next bucket is the third point of the triangle
+		nextStart := int(float64(bucket+1)*bucketSize) + 1
+		nextEnd := min(int(float64(bucket+2)*bucketSize)+1, len(rows))
+		averageX, averageY, count := 0.0, 0.0, 0
+		for i := nextStart; i < nextEnd; i++ {
+			if !math.IsNaN(y[i]) {
+				averageX += x[i]
+				averageY += y[i]
+				count++
+			}
+		}
+		if count > 0 {
+			averageX /= float64(count)
+			averageY /= float64(count)
+		} else {
+			averageX, averageY = x[len(rows)-1], y[len(rows)-1]
+			if math.IsNaN(averageY) {
+				averageY = anchorY
+			}
+		}
+
+		chosen := -1
+		maxArea := -1.0
+		for i := int(float64(bucket)*bucketSize) + 1; i < int(float64(bucket+1)*bucketSize)+1; i++ {
+			area := math.Abs((x[previous]-averageX)*(y[i]-anchorY) - (x[previous]-x[i])*(averageY-anchorY))
+			if !math.IsNaN(area) && area > maxArea {
+				maxArea = area
+				chosen = i
+			}
+		}
+		if chosen >= 0 {
+			sampled = append(sampled, rows[chosen])
+			previous = chosen
+			anchorY = y[chosen]
+		}
+	}
+	return append(sampled, rows[len(rows)-1])
+}
+
+// downsampleBuckets groups rows sorted by time into buckets and reduces each bucket using the method of downsampling
+func downsampleBuckets(rows []*Row, downsampling Downsampling, valueField string) []*Row {
+	if len(rows) == 0 {
+		return rows
+	}
+	maxBuckets := downsampling.MaxPoints
+	if downsampling.Method == DOWNSAMPLE_MIN_MAX {
+		// Each bucket may keep two rows
+		maxBuckets = max(downsampling.MaxPoints/2, 1)
+	}
+	first := rows[0].FieldMap[downsampling.TimeField].(time.Time)
+	last := rows[len(rows)-1].FieldMap[downsampling.TimeField].(time.Time)
+	interval := downsampling.Interval
+	if interval <= 0 {
+		interval = time.Millisecond
+	}
+	// The first bucket starts at the last multiple of the interval since the Unix epoch that is not after the first row.
+	//   The width of a bucket is the smallest multiple of the interval that results in at most maxBuckets buckets
+	origin := first.Add(-time.Duration(positiveModulo(first.UnixNano(), int64(interval))))
+	width := interval * (last.Sub(origin)/(interval*time.Duration(maxBuckets)) + 1)
+	bucketOf := func(row *Row) int64 {
+		return int64(row.FieldMap[downsampling.TimeField].(time.Time).Sub(origin) / width)
+	}
+
+	var r []*Row
+	bucketStart := 0
+	for i := 1; i <= len(rows); i++ {
+		if i < len(rows) && bucketOf(rows[i]) == bucketOf(rows[bucketStart]) {
+			continue
+		}
+		bucket := rows[bucketStart:i]
+		switch downsampling.Method {
+		case DOWNSAMPLE_MEAN:
+			r = append(r, meanRow(bucket, downsampling.TimeField))
+		case DOWNSAMPLE_MIN_MAX:
+			r = append(r, minMaxRows(bucket, valueField)...)
+		default:
+			r = append(r, bucket[len(bucket)-1])
+		}
+		bucketStart = i
+	}
+	return r
+}
+
+// minMaxRows returns the rows of bucket with the minimum and maximum value field in time order, or the last row if no value is numeric
+func minMaxRows(bucket []*Row, valueField string) []*Row {
+	minIndex, maxIndex := -1, -1
+	var minValue, maxValue float64
+	for i, row := range bucket {
+		value, isNumber := toFloat64(row.FieldMap[valueField])
+		if !isNumber {
+			continue
+		}
+		if minIndex < 0 || value < minValue {
+			minIndex, minValue = i, value
+		}
+		if maxIndex < 0 || value > maxValue {
+			maxIndex, maxValue = i, value
+		}
+	}
+	switch {
+	case minIndex < 0:
+		return bucket[len(bucket)-1:]
+	case minIndex == maxIndex:
+		return []*Row{bucket[minIndex]}
+	case minIndex < maxIndex:
+		return []*Row{bucket[minIndex], bucket[maxIndex]}
+	}
+	return []*Row{bucket[maxIndex], bucket[minIndex]}
+}
+
+// meanRow creates a row whose numeric fields are the mean of the bucket, and whose other fields are the last value of the bucket.
+// Its time is the time of the first row of the bucket, so that it is never before the rows it was created from or the range of the query.
+func meanRow(bucket []*Row, timeField string) *Row {
+	row := combineRows(bucket, true)
+	row.FieldMap[timeField] = bucket[0].FieldMap[timeField]
+	return row
+}
+
+// positiveModulo returns a modulo b, which is never negative when b is positive
+func positiveModulo(a int64, b int64) int64 {
+	return ((a % b) + b) % b
+}
+
+// combineRows creates a row whose numeric fields are the sum or mean of rows, and whose other fields are the last value of rows that is not null.
+// Numeric fields keep their type, so the mean of int64 and uint64 values is rounded.
+func combineRows(rows []*Row, average bool) *Row {
+	row := newRow(rows[0].index)
+	sums := map[string]float64{}
+	counts := map[string]int{}
+	for _, combinedRow := range rows {
+		for _, field := range combinedRow.FieldOrder {
+			value, exists := combinedRow.FieldMap[field]
+			if !exists {
+				continue
+			}
+			if _, seen := row.FieldMap[field]; !seen {
+				row.FieldOrder = append(row.FieldOrder, field)
+			}
+			if number, isNumber := toFloat64(value); isNumber {
+				sums[field] += number
+				counts[field]++
+				// Remember the type of the value, which is replaced by the result below
+				row.FieldMap[field] = value
+			} else if _, isNull := value.(jsonnode.Null); !isNull || counts[field] == 0 {
+				row.FieldMap[field] = value
+			}
+		}
+	}
+	for field, count := range counts {
+		if _, isNumber := toFloat64(row.FieldMap[field]); !isNumber {
+			// A value that is not a number came after the numbers
+			continue
+		}
+		result := sums[field]
+		if average {
+			result /= float64(count)
+		}
+		switch row.FieldMap[field].(type) {
+		case jsonnode.Number:
+			row.FieldMap[field] = jsonnode.Number(strconv.FormatFloat(result, 'f', -1, 64))
+		case float64:
+			row.FieldMap[field] = result
+		case int64:
+			row.FieldMap[field] = int64(math.Round(result))
+		case uint64:
+			row.FieldMap[field] = uint64(math.Round(result))
+		}
+	}
+	return row
+}
@@ -3,6 +3,7 @@ package framemap
 import (
 	"github.com/emirpasic/gods/v2/maps/linkedhashmap"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"reflect"
 )
 
 type frameNode struct {
@@ -16,6 +17,33 @@ func keyOfLabels(labels data.Labels) string {
 
 type FrameMap struct {
 	data *linkedhashmap.Map[string, *frameNode]
+	// A cache of the types chosen for fields containing jsonnode.Number values. See numberTypeOf
+	numberTypes map[string]reflect.Type
+	// A cache of the distinct values of enum fields. See enumValuesOf
+	enums map[string]*enumValues
+	// A cache of the errors of fields with mixed types. See mixedTypeErrorOf
+	mixedTypes map[string]*MixedTypeError
+	// The settings of fields, which are applied when creating data frames. See SetFieldSettings
+	fieldSettings map[string]FieldSettings
+	// Names frames and value fields, or is nil. See SetNameFunc
+	nameFunc NameFunc
+	// Fields derived from other fields, which are computed by ToFrames. See AddDerivedField
+	derivedFields []DerivedField
+	// How the number of frames is limited, or nil. See SetSeriesLimit
+	seriesLimit *SeriesLimit
+	// How frames with too many rows are downsampled, or nil. See SetDownsampling
+	downsampling *Downsampling
+	// The field each frame is sorted by, or blank. See SetSorting
+	sortField          string
+	sortDescending     bool
+	deduplication      Deduplication
+	deduplicationField string
+	// The maximum number of rows of each frame, or 0. See SetRowLimit
+	rowLimit        int
+	rowLimitFromEnd bool
+	// The number of rows that have been created
+	rowCount          int
+	mixedTypeHandling MixedTypeHandling
 }
 
 func New() *FrameMap {
@@ -24,6 +52,11 @@ func New() *FrameMap {
 	}
 }
 
+// SetMixedTypeHandling determines what happens when a field has values of different types. The default is MIXED_TYPES_AS_ERROR
+func (f *FrameMap) SetMixedTypeHandling(mixedTypeHandling MixedTypeHandling) {
+	f.mixedTypeHandling = mixedTypeHandling
+}
+
 func (f *FrameMap) getOrCreateFrameNode(labels data.Labels) *frameNode {
 	mapKey := keyOfLabels(labels)
 	values, exists := f.data.Get(mapKey)
@@ -42,7 +75,8 @@ func (f *FrameMap) getOrCreateFrameNode(labels data.Labels) *frameNode {
 func (f *FrameMap) NewRow(labels data.Labels) *Row {
 	node := f.getOrCreateFrameNode(labels)
 
-	row := newRow()
+	row := newRow(f.rowCount)
+	f.rowCount++
 	node.rows = append(node.rows, row)
 	return row
 }
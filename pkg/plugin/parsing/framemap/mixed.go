@@ -0,0 +1,152 @@
+package framemap
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/util/jsonnode"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// the purpose of this file is to handle fields whose values have different types in different rows
+
+type MixedTypeHandling int
+
+const (
+	// MIXED_TYPES_AS_ERROR causes ToFrames to return a *MixedTypeError
+	MIXED_TYPES_AS_ERROR MixedTypeHandling = iota
+	// MIXED_TYPES_AS_STRING converts every value of the field to a string
+	MIXED_TYPES_AS_STRING
+	// MIXED_TYPES_AS_JSON converts every value of the field to raw JSON
+	MIXED_TYPES_AS_JSON
+)
+
+// MixedTypeError is returned by ToFrames when a field has values of different types and mixed types are not allowed.
+// Unlike other errors returned by ToFrames, this error is caused by the data itself.
+type MixedTypeError struct {
+	Field string
+	// Row is the index of the first row whose value does not have the same type as the values before it
+	Row          int
+	ExpectedType reflect.Type
+	ActualType   reflect.Type
+}
+
+func (e *MixedTypeError) Error() string {
+	return fmt.Sprintf("field %s has values of different types! Row %d has type: %v, but earlier rows have type: %v", e.Field, e.Row, e.ActualType, e.ExpectedType)
+}
+
+// typeOf returns the type of a value stored in a Row. All numeric values are considered to be the same type because they are all converted to the same type of field.
+func typeOf(value any) reflect.Type {
+	switch value.(type) {
+	case jsonnode.Number:
+		return reflect.TypeOf(jsonnode.Number(""))
+	}
+	return reflect.TypeOf(value)
+}
+
+// mixedTypeErrorOf returns an error describing the first row of field that has a value whose type is different from the values before it, or nil if every value has the same type.
+// The result is cached, so this should only be called once all rows have been added.
+func (f *FrameMap) mixedTypeErrorOf(field string) *MixedTypeError {
+	if mixedTypeError, cached := f.mixedTypes[field]; cached {
+		return mixedTypeError
+	}
+	var mixedTypeError *MixedTypeError
+	var expectedType reflect.Type
+	frameMapIterator := f.data.Iterator()
+	for frameMapIterator.Next() && mixedTypeError == nil {
+		for _, row := range frameMapIterator.Value().rows {
+			value, exists := row.FieldMap[field]
+			if !exists {
+				continue
+			}
+			if _, isNull := value.(jsonnode.Null); isNull {
+				continue
+			}
+			valueType := typeOf(value)
+			if expectedType == nil {
+				expectedType = valueType
+			} else if valueType != expectedType {
+				mixedTypeError = &MixedTypeError{
+					Field:        field,
+					Row:          row.index,
+					ExpectedType: expectedType,
+					ActualType:   valueType,
+				}
+				break
+			}
+		}
+	}
+	if f.mixedTypes == nil {
+		f.mixedTypes = map[string]*MixedTypeError{}
+	}
+	f.mixedTypes[field] = mixedTypeError
+	return mixedTypeError
+}
+
+// valueToString converts a value stored in a Row to a string
+func valueToString(value any) string {
+	switch typedValue := value.(type) {
+	case string:
+		return typedValue
+	case EnumValue:
+		return string(typedValue)
+	case bool:
+		return strconv.FormatBool(typedValue)
+	case float64:
+		return strconv.FormatFloat(typedValue, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(typedValue, 10)
+	case uint64:
+		return strconv.FormatUint(typedValue, 10)
+	case time.Time:
+		return typedValue.Format(time.RFC3339Nano)
+	case json.RawMessage:
+		return string(typedValue)
+	case jsonnode.Node:
+		return string(typedValue.Serialize())
+	}
+	return fmt.Sprint(value)
+}
+
+// valueToJson converts a value stored in a Row to raw JSON
+func valueToJson(value any) (json.RawMessage, error) {
+	switch typedValue := value.(type) {
+	case json.RawMessage:
+		return typedValue, nil
+	case jsonnode.Node:
+		return typedValue.Serialize(), nil
+	}
+	return json.Marshal(value)
+}
+
+func createFieldForMixedTypes(node *frameNode, field string, mixedTypeHandling MixedTypeHandling) (*data.Field, error) {
+	if mixedTypeHandling == MIXED_TYPES_AS_STRING {
+		var values []*string
+		for _, row := range node.rows {
+			value, exists := row.FieldMap[field]
+			if _, isNull := value.(jsonnode.Null); !exists || isNull {
+				values = append(values, nil)
+				continue
+			}
+			stringValue := valueToString(value)
+			values = append(values, &stringValue)
+		}
+		return data.NewField(field, node.labels, values), nil
+	}
+	var values []*json.RawMessage
+	for _, row := range node.rows {
+		value, exists := row.FieldMap[field]
+		if !exists {
+			values = append(values, nil)
+			continue
+		}
+		jsonValue, err := valueToJson(value)
+		if err != nil {
+			return nil, fmt.Errorf("field %s of row %d could not be converted to JSON: %w", field, row.index, err)
+		}
+		values = append(values, &jsonValue)
+	}
+	return data.NewField(field, node.labels, values), nil
+}
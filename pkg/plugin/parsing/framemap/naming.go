@@ -0,0 +1,26 @@
+package framemap
+
+import (
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// NameFunc returns the name of a frame with the given labels when fieldName is blank, or otherwise the display name of the field in that frame
+type NameFunc func(labels data.Labels, fieldName string) string
+
+// SetNameFunc sets the function used to name frames and value fields. When not set, frames are named "response <labels>" and fields have no display name.
+func (f *FrameMap) SetNameFunc(nameFunc NameFunc) {
+	f.nameFunc = nameFunc
+}
+
+// applyDisplayName sets the display name of a value field if it does not already have one
+func (f *FrameMap) applyDisplayName(labels data.Labels, field *data.Field) {
+	if f.nameFunc == nil || field.Type().Time() {
+		return
+	}
+	if field.Config == nil {
+		field.Config = &data.FieldConfig{}
+	}
+	if field.Config.DisplayNameFromDS == "" {
+		field.Config.DisplayNameFromDS = f.nameFunc(labels, field.Name)
+	}
+}
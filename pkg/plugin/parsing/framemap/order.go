@@ -0,0 +1,201 @@
+package framemap
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// the purpose of this file is to sort, deduplicate and limit the rows of each frame
+
+type Deduplication int
+
+const (
+	// KEEP_DUPLICATES keeps every row
+	KEEP_DUPLICATES Deduplication = iota
+	// DROP_EXACT_DUPLICATES keeps the first of the rows that have the same value for every field
+	DROP_EXACT_DUPLICATES
+	// DROP_SAME_KEY keeps the last of the rows that have the same value for the deduplication field
+	DROP_SAME_KEY
+)
+
+// SetSorting sets the field each frame is sorted by, or a blank field to keep the order rows were created.
+// Rows whose field is null or missing are always sorted last.
+func (f *FrameMap) SetSorting(field string, descending bool) {
+	f.sortField = field
+	f.sortDescending = descending
+}
+
+// SetDeduplication determines how duplicate rows of each frame are dropped. field is only used by DROP_SAME_KEY
+func (f *FrameMap) SetDeduplication(deduplication Deduplication, field string) {
+	f.deduplication = deduplication
+	f.deduplicationField = field
+}
+
+// SetRowLimit limits the number of rows of each frame to limit, or 0 for no limit. When fromEnd is true, the last rows are kept rather than the first rows
+func (f *FrameMap) SetRowLimit(limit int, fromEnd bool) {
+	f.rowLimit = limit
+	f.rowLimitFromEnd = fromEnd
+}
+
+// orderRows sorts and deduplicates the rows of each frame node
+func (f *FrameMap) orderRows() {
+	frameMapIterator := f.data.Iterator()
+	for frameMapIterator.Next() {
+		node := frameMapIterator.Value()
+		f.sortRows(node)
+		switch f.deduplication {
+		case DROP_EXACT_DUPLICATES:
+			node.rows = dropExactDuplicates(node.rows)
+		case DROP_SAME_KEY:
+			node.rows = dropSameKey(node.rows, f.deduplicationField)
+		}
+	}
+}
+
+// sortRows sorts the rows of node by the sort field, if there is one
+func (f *FrameMap) sortRows(node *frameNode) {
+	if f.sortField == "" {
+		return
+	}
+	slices.SortStableFunc(node.rows, func(a *Row, b *Row) int {
+		return compareRows(a, b, f.sortField, f.sortDescending)
+	})
+}
+
+// limitRows limits the number of rows of each frame node
+func (f *FrameMap) limitRows() {
+	if f.rowLimit <= 0 {
+		return
+	}
+	frameMapIterator := f.data.Iterator()
+	for frameMapIterator.Next() {
+		node := frameMapIterator.Value()
+		if len(node.rows) <= f.rowLimit {
+			continue
+		}
+		if f.rowLimitFromEnd {
+			node.rows = node.rows[len(node.rows)-f.rowLimit:]
+		} else {
+			node.rows = node.rows[:f.rowLimit]
+		}
+	}
+}
+
+// compareRows compares the values of field. Rows whose value cannot be compared are sorted last regardless of descending.
+func compareRows(a *Row, b *Row, field string, descending bool) int {
+	result, comparableA, comparableB := compareValues(a.FieldMap[field], b.FieldMap[field])
+	if !comparableA || !comparableB {
+		if comparableA == comparableB {
+			return 0
+		}
+		if comparableA {
+			return -1
+		}
+		return 1
+	}
+	if descending {
+		return -result
+	}
+	return result
+}
+
+// compareValues compares two values stored in a Row. comparableA and comparableB are false for values that cannot be compared, such as nulls.
+// Values of different types cannot be compared with each other, so only a is considered comparable in that case.
+func compareValues(a any, b any) (result int, comparableA bool, comparableB bool) {
+	switch typedA := a.(type) {
+	case time.Time:
+		typedB, isTime := b.(time.Time)
+		return typedA.Compare(typedB), true, isTime
+	case string:
+		typedB, isString := b.(string)
+		return strings.Compare(typedA, typedB), true, isString
+	case EnumValue:
+		typedB, isEnum := b.(EnumValue)
+		return strings.Compare(string(typedA), string(typedB)), true, isEnum
+	case bool:
+		typedB, isBool := b.(bool)
+		if typedA == typedB {
+			return 0, true, isBool
+		} else if typedB {
+			return -1, true, isBool
+		}
+		return 1, true, isBool
+	case int64:
+		if typedB, isInt := b.(int64); isInt {
+			// Compared directly to keep the precision of large integers
+			return cmp.Compare(typedA, typedB), true, true
+		}
+	case uint64:
+		if typedB, isUint := b.(uint64); isUint {
+			return cmp.Compare(typedA, typedB), true, true
+		}
+	}
+	numberA, isNumberA := toFloat64(a)
+	numberB, isNumberB := toFloat64(b)
+	if !isNumberA {
+		return 0, false, isComparable(b)
+	}
+	return cmp.Compare(numberA, numberB), true, isNumberB
+}
+
+// isComparable determines if value can be compared by compareValues
+func isComparable(value any) bool {
+	switch value.(type) {
+	case time.Time, string, EnumValue, bool:
+		return true
+	}
+	_, isNumber := toFloat64(value)
+	return isNumber
+}
+
+// keyOfValue returns a string that is equal for equal values stored in a Row
+func keyOfValue(value any) string {
+	switch typedValue := value.(type) {
+	case time.Time:
+		return fmt.Sprintf("%T:%d", value, typedValue.UnixNano())
+	case json.RawMessage:
+		return fmt.Sprintf("%T:%s", value, typedValue)
+	}
+	return fmt.Sprintf("%T:%v", value, value)
+}
+
+// dropExactDuplicates keeps the first of the rows that have the same value for every field
+func dropExactDuplicates(rows []*Row) []*Row {
+	seen := map[string]bool{}
+	var r []*Row
+	for _, row := range rows {
+		fields := make([]string, 0, len(row.FieldMap))
+		for field, value := range row.FieldMap {
+			fields = append(fields, fmt.Sprintf("%q=%s", field, keyOfValue(value)))
+		}
+		slices.Sort(fields)
+		key := strings.Join(fields, ",")
+		if !seen[key] {
+			seen[key] = true
+			r = append(r, row)
+		}
+	}
+	return r
+}
+
+// dropSameKey keeps the last of the rows that have the same value for field. Rows without the field are always kept.
+func dropSameKey(rows []*Row, field string) []*Row {
+	lastIndexes := map[string]int{}
+	for i, row := range rows {
+		if value, exists := row.FieldMap[field]; exists {
+			lastIndexes[keyOfValue(value)] = i
+		}
+	}
+	var r []*Row
+	for i, row := range rows {
+		value, exists := row.FieldMap[field]
+		if !exists || lastIndexes[keyOfValue(value)] == i {
+			r = append(r, row)
+		}
+	}
+	return r
+}
@@ -6,15 +6,26 @@ type Row struct {
 	//
 	//  jsonnode.Number, jsonnode.Null
 	//
-	//  string, bool, float64, time.Time
+	//  string, bool, float64, int64, uint64, time.Time
+	//
+	//  json.RawMessage, EnumValue
+	//
+	// A field of jsonnode.Number values becomes an int64 field if every value is an integer that fits, then a uint64 field, and a float64 field otherwise.
 	//
 	// Please make sure you do not use pointer types.
 	FieldMap map[string]any
+	// The index of this row in the order rows were created
+	index int
 }
 
-func newRow() *Row {
+// EnumValue is a value stored in a Row whose field should become an enum field.
+// Each distinct value of the field is assigned an index in the order the values are first seen.
+type EnumValue string
+
+func newRow(index int) *Row {
 	row := Row{
 		FieldMap: map[string]any{},
+		index:    index,
 	}
 	return &row
 }
@@ -0,0 +1,170 @@
+package framemap
+
+import (
+	"fmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"math"
+	"slices"
+	"sort"
+	"time"
+)
+
+// the purpose of this file is to limit the number of frames to the series ranked highest or lowest by an aggregate of a field
+
+type AggregateType int
+
+const (
+	AGGREGATE_LAST AggregateType = iota
+	AGGREGATE_MAX
+	AGGREGATE_MEAN
+	AGGREGATE_SUM
+)
+
+// OTHER_SERIES_LABEL_VALUE is the value of every label of the series that remaining series are merged into, unless a kept series has the same labels
+const OTHER_SERIES_LABEL_VALUE = "other"
+
+type SeriesLimit struct {
+	// Limit is the number of series that are kept
+	Limit int
+	// When true, the series with the lowest aggregate are kept rather than the series with the highest aggregate
+	Bottom bool
+	// Field is the key of the field that series are ranked by. A blank value uses the first numeric field
+	Field     string
+	Aggregate AggregateType
+	// When true, the remaining series are merged into a single series whose labels all have the value OTHER_SERIES_LABEL_VALUE.
+	//   Otherwise, the remaining series are dropped
+	MergeRemaining bool
+	// TimeField is the key of the time field. When remaining series are merged, the numeric fields of rows with the same time are summed.
+	//   A blank value merges the rows of remaining series without combining them
+	TimeField string
+}
+
+// SetSeriesLimit sets how the number of frames returned by ToFrames is limited, or nil for no limit
+func (f *FrameMap) SetSeriesLimit(seriesLimit *SeriesLimit) {
+	f.seriesLimit = seriesLimit
+}
+
+// aggregate computes the aggregate of the numeric values of field in rows. ok is false if there are no numeric values.
+func aggregate(rows []*Row, field string, aggregateType AggregateType) (result float64, ok bool) {
+	count := 0
+	for _, row := range rows {
+		value, isNumber := toFloat64(row.FieldMap[field])
+		if !isNumber || math.IsNaN(value) {
+			continue
+		}
+		switch {
+		case count == 0, aggregateType == AGGREGATE_LAST:
+			result = value
+		case aggregateType == AGGREGATE_MAX:
+			result = max(result, value)
+		default:
+			result += value
+		}
+		count++
+	}
+	if count > 0 && aggregateType == AGGREGATE_MEAN {
+		result /= float64(count)
+	}
+	return result, count > 0
+}
+
+// limitSeries keeps the series ranked highest (or lowest) and drops or merges the remaining series. Series without a numeric value are always ranked last.
+// Kept series keep their order, and the merged series is last.
+func (f *FrameMap) limitSeries() {
+	seriesLimit := f.seriesLimit
+	if seriesLimit == nil || seriesLimit.Limit <= 0 || f.data.Size() <= seriesLimit.Limit {
+		return
+	}
+	field := seriesLimit.Field
+	if field == "" {
+		field = f.firstNumericField(seriesLimit.TimeField)
+	}
+	type rankedSeries struct {
+		key    string
+		node   *frameNode
+		value  float64
+		ranked bool
+	}
+	var series []rankedSeries
+	frameMapIterator := f.data.Iterator()
+	for frameMapIterator.Next() {
+		value, ranked := aggregate(frameMapIterator.Value().rows, field, seriesLimit.Aggregate)
+		series = append(series, rankedSeries{key: frameMapIterator.Key(), node: frameMapIterator.Value(), value: value, ranked: ranked})
+	}
+	sort.SliceStable(series, func(i, j int) bool {
+		if !series[i].ranked || !series[j].ranked {
+			return series[i].ranked && !series[j].ranked
+		}
+		if seriesLimit.Bottom {
+			return series[i].value < series[j].value
+		}
+		return series[i].value > series[j].value
+	})
+
+	var remainingRows []*Row
+	var labelNames []string
+	for _, remaining := range series[seriesLimit.Limit:] {
+		f.data.Remove(remaining.key)
+		remainingRows = append(remainingRows, remaining.node.rows...)
+		for name := range remaining.node.labels {
+			if !slices.Contains(labelNames, name) {
+				labelNames = append(labelNames, name)
+			}
+		}
+	}
+	if !seriesLimit.MergeRemaining {
+		return
+	}
+	node := f.getOrCreateFrameNode(f.otherSeriesLabels(labelNames))
+	node.rows = append(node.rows, mergeRows(remainingRows, seriesLimit.TimeField)...)
+	if seriesLimit.TimeField != "" {
+		sortRowsByTime(node.rows, seriesLimit.TimeField)
+	}
+	f.sortRows(node)
+}
+
+// otherSeriesLabels returns the labels of the series that remaining series are merged into, where each of labelNames has the value OTHER_SERIES_LABEL_VALUE.
+// If a kept series already has those labels, a number is added to the value, such as "other 2", so that the remaining series are not merged into a kept series.
+func (f *FrameMap) otherSeriesLabels(labelNames []string) data.Labels {
+	for n := 1; ; n++ {
+		value := OTHER_SERIES_LABEL_VALUE
+		if n > 1 {
+			value = fmt.Sprintf("%s %d", OTHER_SERIES_LABEL_VALUE, n)
+		}
+		labels := data.Labels{}
+		for _, name := range labelNames {
+			labels[name] = value
+		}
+		// Without any label names, there is no kept series with the same labels, because the series without labels is one of the remaining series
+		if _, exists := f.data.Get(keyOfLabels(labels)); !exists || len(labelNames) == 0 {
+			return labels
+		}
+	}
+}
+
+// mergeRows combines rows that have the same time by summing their numeric fields. Rows without a time are kept as they are.
+func mergeRows(rows []*Row, timeField string) []*Row {
+	if timeField == "" {
+		return rows
+	}
+	var keys []int64
+	rowsByTime := map[int64][]*Row{}
+	var r []*Row
+	for _, row := range rows {
+		rowTime, hasTime := row.FieldMap[timeField].(time.Time)
+		if !hasTime {
+			r = append(r, row)
+			continue
+		}
+		key := rowTime.UnixNano()
+		if _, seen := rowsByTime[key]; !seen {
+			keys = append(keys, key)
+		}
+		rowsByTime[key] = append(rowsByTime[key], row)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		r = append(r, combineRows(rowsByTime[key], false))
+	}
+	return r
+}
@@ -0,0 +1,71 @@
+package framemap
+
+import (
+	"fmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// FieldSettings are applied to a field when it is added to a data frame
+type FieldSettings struct {
+	// Name replaces the key as the name of the field. A blank value uses the key
+	Name string
+	// Config is merged into the config of the field, or is nil
+	Config *data.FieldConfig
+}
+
+// SetFieldSettings sets the settings of the field at key, replacing any previous settings of that field
+func (f *FrameMap) SetFieldSettings(key string, settings FieldSettings) {
+	if f.fieldSettings == nil {
+		f.fieldSettings = map[string]FieldSettings{}
+	}
+	f.fieldSettings[key] = settings
+}
+
+// applyFieldSettings applies the settings of the field at key to field
+func (f *FrameMap) applyFieldSettings(key string, field *data.Field) {
+	settings, exists := f.fieldSettings[key]
+	if !exists {
+		return
+	}
+	if settings.Name != "" {
+		field.Name = settings.Name
+	}
+	if settings.Config != nil {
+		// Each field gets its own copy, so that fields of different frames may be changed independently
+		config := *settings.Config
+		if field.Config != nil && config.TypeConfig == nil {
+			// Keep the type config, which holds the values of enum fields
+			config.TypeConfig = field.Config.TypeConfig
+		}
+		field.Config = &config
+	}
+}
+
+// FieldNameCollisionError is returned by ToFrames when the name given to a field is the same as the name of another field.
+// Like MixedTypeError, this error is caused by the configuration or the data rather than the plugin itself.
+type FieldNameCollisionError struct {
+	Name string
+	// Field and OtherField are the keys of the fields that have the same name
+	Field      string
+	OtherField string
+}
+
+func (e *FieldNameCollisionError) Error() string {
+	return fmt.Sprintf("fields %s and %s both have the name: %s", e.OtherField, e.Field, e.Name)
+}
+
+// checkFieldNames returns a *FieldNameCollisionError if two of fields would have the same name once their settings are applied
+func (f *FrameMap) checkFieldNames(fields []string) error {
+	keysByName := map[string]string{}
+	for _, key := range fields {
+		name := key
+		if settings, exists := f.fieldSettings[key]; exists && settings.Name != "" {
+			name = settings.Name
+		}
+		if otherKey, exists := keysByName[name]; exists {
+			return &FieldNameCollisionError{Name: name, Field: key, OtherField: otherKey}
+		}
+		keysByName[name] = key
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package parsing
+
+import (
+	"fmt"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"strconv"
+	"strings"
+)
+
+// the purpose of this file is to build the keys of flattened data given the key format options of a querymodel.ParsingOption
+
+const (
+	DEFAULT_KEY_SEPARATOR = "."
+	// DEFAULT_NESTED_ARRAY_MARKER is the name used in place of an index for arrays nested directly within exploded arrays.
+	//   This is something we made up, as there is no key in the JSON itself.
+	DEFAULT_NESTED_ARRAY_MARKER = "_"
+)
+
+// keyFormat determines how keys are joined together when flattening data. The zero value uses the default format, such as "values.0.name"
+type keyFormat struct {
+	// separator is placed between the parts of a key. A blank value is the same as DEFAULT_KEY_SEPARATOR
+	separator string
+	// bracketIndexes is true when array indexes are formatted like "values[0]" rather than "values.0"
+	bracketIndexes bool
+	// nestedArrayMarker is the part of a key used for arrays nested directly within exploded arrays. A blank value is the same as DEFAULT_NESTED_ARRAY_MARKER
+	nestedArrayMarker string
+}
+
+// newKeyFormat creates a keyFormat or returns a friendly error if the key format options are misconfigured
+func newKeyFormat(parsingOption querymodel.ParsingOption) (keyFormat, error) {
+	for _, value := range []string{parsingOption.KeySeparator, parsingOption.NestedArrayMarker} {
+		// "*" is reserved for wildcards and "#" is reserved for keys created by this plugin
+		if strings.ContainsAny(value, "*#") {
+			return keyFormat{}, fmt.Errorf("the key separator and nested array marker may not contain \"*\" or \"#\"! value: %s", value)
+		}
+	}
+	if parsingOption.NestedArrayMarker != "" && parsingOption.NestedArrayMarker == parsingOption.KeySeparator {
+		return keyFormat{}, fmt.Errorf("the nested array marker must be different from the key separator! value: %s", parsingOption.NestedArrayMarker)
+	}
+	var bracketIndexes bool
+	switch parsingOption.ArrayIndexFormat {
+	case querymodel.SEPARATOR_INDEX, "":
+		bracketIndexes = false
+	case querymodel.BRACKET_INDEX:
+		bracketIndexes = true
+	default:
+		return keyFormat{}, fmt.Errorf("unknown array index format: %s", parsingOption.ArrayIndexFormat)
+	}
+	return keyFormat{
+		separator:         parsingOption.KeySeparator,
+		bracketIndexes:    bracketIndexes,
+		nestedArrayMarker: parsingOption.NestedArrayMarker,
+	}, nil
+}
+
+func (format keyFormat) getSeparator() string {
+	if format.separator == "" {
+		return DEFAULT_KEY_SEPARATOR
+	}
+	return format.separator
+}
+
+// prefixOf returns the prefix of the keys nested within the object at key
+func (format keyFormat) prefixOf(key string) string {
+	return key + format.getSeparator()
+}
+
+// indexKey returns the key of the element at index of the array at arrayKey
+func (format keyFormat) indexKey(arrayKey string, index int) string {
+	if format.bracketIndexes {
+		return arrayKey + "[" + strconv.Itoa(index) + "]"
+	}
+	return format.prefixOf(arrayKey) + strconv.Itoa(index)
+}
+
+// nestedArrayKey returns the key of an array nested directly within the exploded array at arrayKey
+func (format keyFormat) nestedArrayKey(arrayKey string) string {
+	if format.nestedArrayMarker == "" {
+		return format.prefixOf(arrayKey) + DEFAULT_NESTED_ARRAY_MARKER
+	}
+	return format.prefixOf(arrayKey) + format.nestedArrayMarker
+}
+
+// depthOf returns the number of levels key is nested within the data. Top level keys have a depth of 0.
+func (format keyFormat) depthOf(key string) int {
+	depth := strings.Count(key, format.getSeparator())
+	if format.bracketIndexes {
+		depth += strings.Count(key, "[")
+	}
+	return depth
+}
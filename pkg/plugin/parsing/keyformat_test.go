@@ -0,0 +1,60 @@
+package parsing
+
+import (
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"testing"
+)
+
+func TestKeyFormat(t *testing.T) {
+	jsonString := `
+{
+  "data": [
+    {
+      "device": { "name": "a", "createdAt": 1704333209773 },
+      "tags": ["x", "y"],
+      "readings": [[1, 2], [3, 4]]
+    }
+  ]
+}
+`
+	frames, err := parseTestData(t, jsonString, querymodel.ParsingOption{
+		DataPath:          "data",
+		KeySeparator:      "_",
+		ArrayIndexFormat:  querymodel.BRACKET_INDEX,
+		NestedArrayMarker: "item",
+		ExplodeArrayPaths: []string{"readings"},
+		TimeFields:        []querymodel.TimeField{{TimePath: "device_createdAt"}},
+		LabelOptions:      []querymodel.LabelOption{{Name: "device", Type: querymodel.FIELD, Value: "device_name"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := frames[0]
+	if frame.Fields[0].Labels["device"] != "a" {
+		t.Errorf("Unexpected labels: %v", frame.Fields[0].Labels)
+	}
+	var names []string
+	for _, field := range frame.Fields {
+		names = append(names, field.Name)
+	}
+	expectedNames := []string{"device_name", "device_createdAt", "tags[0]", "tags[1]", "readings_item[0]", "readings_item[1]"}
+	if len(names) != len(expectedNames) {
+		t.Fatalf("Unexpected field names: %v", names)
+	}
+	for i, name := range expectedNames {
+		if names[i] != name {
+			t.Errorf("Expected field %d to be %s but was %s", i, name, names[i])
+		}
+	}
+	if !frame.Fields[1].Type().Time() {
+		t.Error("Expected device_createdAt to be a time field")
+	}
+	if frame.Rows() != 2 {
+		t.Errorf("Expected 2 rows but got %d", frame.Rows())
+	}
+
+	_, err = parseTestData(t, jsonString, querymodel.ParsingOption{DataPath: "data", KeySeparator: "#"})
+	if err == nil {
+		t.Error("Expected an error for a key separator containing \"#\"")
+	}
+}
@@ -0,0 +1,80 @@
+package parsing
+
+import (
+	"encoding/json"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"strings"
+	"testing"
+)
+
+func TestMixedTypes(t *testing.T) {
+	jsonString := `
+{
+  "data": [
+    { "id": "a", "value": 1.5 },
+    { "id": "b", "value": "high" },
+    { "id": "c", "value": true },
+    { "id": "d", "value": null }
+  ]
+}
+`
+	_, err := parseTestData(t, jsonString, querymodel.ParsingOption{DataPath: "data"})
+	if err == nil || !strings.Contains(err.Error(), "value") || !strings.Contains(err.Error(), "Row 1") {
+		t.Errorf("Expected an error that names the field and row, but got: %v", err)
+	}
+
+	frames, err := parseTestData(t, jsonString, querymodel.ParsingOption{DataPath: "data", MixedTypePolicy: querymodel.PROMOTE_TO_STRING})
+	if err != nil {
+		t.Fatal(err)
+	}
+	field, _ := frames[0].FieldByName("value")
+	for i, expected := range []any{"1.5", "high", "true", nil} {
+		actual, ok := field.ConcreteAt(i)
+		if !ok {
+			actual = nil
+		}
+		if actual != expected {
+			t.Errorf("Expected value[%d] to be %v but was %v", i, expected, actual)
+		}
+	}
+
+	frames, err = parseTestData(t, jsonString, querymodel.ParsingOption{DataPath: "data", MixedTypePolicy: querymodel.PROMOTE_TO_JSON})
+	if err != nil {
+		t.Fatal(err)
+	}
+	field, _ = frames[0].FieldByName("value")
+	for i, expected := range []string{`1.5`, `"high"`, `true`, `null`} {
+		actual, _ := field.ConcreteAt(i)
+		if string(actual.(json.RawMessage)) != expected {
+			t.Errorf("Expected value[%d] to be %s but was %s", i, expected, actual)
+		}
+	}
+}
+
+func TestMixedTypesAcrossFrames(t *testing.T) {
+	jsonString := `
+{
+  "data": [
+    { "host": "a", "value": 1 },
+    { "host": "b", "value": "unknown" }
+  ]
+}
+`
+	frames, err := parseTestData(t, jsonString, querymodel.ParsingOption{
+		DataPath:        "data",
+		MixedTypePolicy: querymodel.PROMOTE_TO_STRING,
+		LabelOptions:    []querymodel.LabelOption{{Name: "host", Type: querymodel.FIELD, Value: "host"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 2 {
+		t.Fatalf("Expected 2 frames but got %d", len(frames))
+	}
+	for _, frame := range frames {
+		field, _ := frame.FieldByName("value")
+		if _, isString := field.At(0).(*string); !isString {
+			t.Errorf("Expected value of frame %s to be a string field", frame.Name)
+		}
+	}
+}
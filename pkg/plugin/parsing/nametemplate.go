@@ -0,0 +1,41 @@
+package parsing
+
+import (
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"regexp"
+	"strings"
+)
+
+// the purpose of this file is to render the name template of a querymodel.ParsingOption, such as "{{host}} {{__field}}"
+
+const (
+	// FIELD_PLACEHOLDER is replaced with the name of the field, or a blank string within the name of a frame
+	FIELD_PLACEHOLDER = "__field"
+	// REF_ID_PLACEHOLDER is replaced with the refId of the query
+	REF_ID_PLACEHOLDER = "__refId"
+	// LABELS_PLACEHOLDER is replaced with every label, such as {host=a, sensor=b}, or a blank string if there are no labels
+	LABELS_PLACEHOLDER = "__labels"
+)
+
+var placeholderRegex = regexp.MustCompile(`\{\{\s*([^{}]*?)\s*}}`)
+
+// renderNameTemplate replaces each placeholder of template. Any other placeholder is the name of a label, which is replaced with a blank string if the label does not exist.
+// Because placeholders may be blank, runs of whitespace in the rendered name are collapsed into a single space.
+func renderNameTemplate(template string, labels data.Labels, fieldName string, refId string) string {
+	rendered := placeholderRegex.ReplaceAllStringFunc(template, func(placeholder string) string {
+		name := placeholderRegex.FindStringSubmatch(placeholder)[1]
+		switch name {
+		case FIELD_PLACEHOLDER:
+			return fieldName
+		case REF_ID_PLACEHOLDER:
+			return refId
+		case LABELS_PLACEHOLDER:
+			if len(labels) == 0 {
+				return ""
+			}
+			return "{" + labels.String() + "}"
+		}
+		return labels[name]
+	})
+	return strings.Join(strings.Fields(rendered), " ")
+}
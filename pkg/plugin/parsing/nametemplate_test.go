@@ -0,0 +1,50 @@
+package parsing
+
+import (
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"testing"
+)
+
+func TestNameTemplate(t *testing.T) {
+	displayName := "Humidity"
+	frames, err := parseTestData(t, `{"data": [{"time": 1, "host": "a", "temperature": 20, "humidity": 0.5}, {"time": 1, "host": "b", "temperature": 21, "humidity": 0.6}]}`, querymodel.ParsingOption{
+		DataPath:     "data",
+		TimeFields:   []querymodel.TimeField{{TimePath: "time"}},
+		NameTemplate: "{{ host }} {{__field}} ({{__refId}}){{missing}}",
+		LabelOptions: []querymodel.LabelOption{{Name: "host", Type: querymodel.FIELD, Value: "host"}},
+		FieldConfigs: []querymodel.FieldConfig{{FieldPath: "humidity", DisplayName: displayName}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frames[0].Name != "a (A)" || frames[1].Name != "b (A)" {
+		t.Errorf("Unexpected frame names: %s, %s", frames[0].Name, frames[1].Name)
+	}
+	time, _ := frames[1].FieldByName("time")
+	if time.Config != nil && time.Config.DisplayNameFromDS != "" {
+		t.Errorf("Expected the time field to not have a display name: %s", time.Config.DisplayNameFromDS)
+	}
+	temperature, _ := frames[1].FieldByName("temperature")
+	if temperature.Config == nil || temperature.Config.DisplayNameFromDS != "b temperature (A)" {
+		t.Errorf("Unexpected display name: %+v", temperature.Config)
+	}
+	humidity, _ := frames[1].FieldByName("humidity")
+	if humidity.Config.DisplayNameFromDS != displayName {
+		t.Errorf("Expected the display name of the field config to take precedence: %s", humidity.Config.DisplayNameFromDS)
+	}
+
+	frames, err = parseTestData(t, `{"data": [{"host": "a", "sensor": "b", "temperature": 20}]}`, querymodel.ParsingOption{
+		DataPath:     "data",
+		NameTemplate: "{{__field}} {{__labels}}",
+		LabelOptions: []querymodel.LabelOption{
+			{Name: "host", Type: querymodel.FIELD, Value: "host"},
+			{Name: "sensor", Type: querymodel.FIELD, Value: "sensor"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frames[0].Name != "{host=a, sensor=b}" {
+		t.Errorf("Unexpected frame name: %s", frames[0].Name)
+	}
+}
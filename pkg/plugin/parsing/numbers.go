@@ -0,0 +1,91 @@
+package parsing
+
+import (
+	"fmt"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/util/jsonnode"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// the purpose of this file is to convert numbers into the type configured by querymodel.NumberType
+
+// convertNumber converts a number into a value that can be stored in a framemap.Row. Any error returned is a friendly error.
+func convertNumber(key string, number jsonnode.Number, numberType querymodel.NumberType) (any, error) {
+	switch numberType {
+	case querymodel.FLOAT64, "":
+		value, err := number.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("could not parse number: %s of field: %s", number, key)
+		}
+		return value, nil
+	case querymodel.INT64:
+		value, err := number.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("number: %s of field: %s is not an integer that fits in an int64", number, key)
+		}
+		return value, nil
+	case querymodel.UINT64:
+		value, err := number.Uint64()
+		if err != nil {
+			return nil, fmt.Errorf("number: %s of field: %s is not a non-negative integer that fits in a uint64", number, key)
+		}
+		return value, nil
+	case querymodel.AUTO:
+		// The framemap decides the type once it has seen every value of this field
+		return number, nil
+	}
+	return nil, fmt.Errorf("unknown number type: %s for field: %s", numberType, key)
+}
+
+// parseNumericString parses a string such as the value of a BigInt or Long custom scalar into a number.
+// A blank string results in jsonnode.NULL. Any error returned is a friendly error.
+func parseNumericString(key string, value jsonnode.String) (jsonnode.Node, error) {
+	trimmed := strings.TrimSpace(value.String())
+	if trimmed == "" {
+		return jsonnode.NULL, nil
+	}
+	node, err := jsonnode.Decode([]byte(trimmed))
+	if err != nil {
+		return nil, fmt.Errorf("value: %s of field: %s is not a number", value, key)
+	}
+	if _, isNumber := node.(jsonnode.Number); !isNumber {
+		return nil, fmt.Errorf("value: %s of field: %s is not a number", value, key)
+	}
+	return node, nil
+}
+
+// scaleNumber multiplies number by the scale of numberField and then adds its offset. number is returned unchanged if numberField is nil or has no scale or offset.
+// The arithmetic is exact, so that integers that do not fit in a float64, such as those of INT64 and UINT64 fields, are scaled without losing precision.
+// Any error returned is a friendly error.
+func scaleNumber(key string, number jsonnode.Number, numberField *querymodel.NumberField) (jsonnode.Number, error) {
+	if numberField == nil || (numberField.Scale == nil && numberField.Offset == nil) {
+		return number, nil
+	}
+	value, ok := new(big.Rat).SetString(number.String())
+	if !ok {
+		return "", fmt.Errorf("could not parse number: %s of field: %s", number, key)
+	}
+	if numberField.Scale != nil {
+		value.Mul(value, decimalRat(*numberField.Scale))
+	}
+	if numberField.Offset != nil {
+		value.Add(value, decimalRat(*numberField.Offset))
+	}
+	if value.IsInt() {
+		return jsonnode.Number(value.Num().String()), nil
+	}
+	floatValue, _ := value.Float64()
+	if math.IsInf(floatValue, 0) {
+		return "", fmt.Errorf("number: %s of field: %s is out of range after it is scaled", number, key)
+	}
+	return jsonnode.Number(strconv.FormatFloat(floatValue, 'f', -1, 64)), nil
+}
+
+// decimalRat converts value into the decimal it is written as, such as exactly 0.001 rather than the float64 closest to it
+func decimalRat(value float64) *big.Rat {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(value, 'g', -1, 64))
+	return r
+}
@@ -0,0 +1,163 @@
+package parsing
+
+import (
+	"encoding/json"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/util/jsonnode"
+	"testing"
+	"time"
+)
+
+func parseTestData(t *testing.T, jsonString string, parsingOption querymodel.ParsingOption) (data.Frames, error) {
+	object, err := jsonnode.Decode([]byte(jsonString))
+	if err != nil {
+		t.Fatal("Could not unmarshal JSON", err)
+	}
+	frames, _, err := ParseData(object.(*jsonnode.Object), parsingOption, backend.DataQuery{RefID: "A"})
+	return frames, err
+}
+
+// valuesOf returns the values of the field named fieldName, or nil if there is no such field.
+// Null values are nil, times are in UTC and JSON values are strings, so that the values can be compared with ==
+func valuesOf(frame *data.Frame, fieldName string) []any {
+	field, _ := frame.FieldByName(fieldName)
+	if field == nil {
+		return nil
+	}
+	values := make([]any, field.Len())
+	for i := range values {
+		value, ok := field.ConcreteAt(i)
+		if !ok {
+			continue
+		}
+		switch typedValue := value.(type) {
+		case time.Time:
+			value = typedValue.UTC()
+		case json.RawMessage:
+			value = string(typedValue)
+		}
+		values[i] = value
+	}
+	return values
+}
+
+// assertFieldValues checks that each field of frame has the expected values. See valuesOf
+func assertFieldValues(t *testing.T, frame *data.Frame, expectedValues map[string][]any) {
+	t.Helper()
+	for fieldName, expected := range expectedValues {
+		actual := valuesOf(frame, fieldName)
+		if actual == nil {
+			t.Errorf("Expected field %s to exist", fieldName)
+			continue
+		}
+		if len(actual) != len(expected) {
+			t.Errorf("Expected %s to have %d values but it has %d: %v", fieldName, len(expected), len(actual), actual)
+			continue
+		}
+		for i := range expected {
+			if actual[i] != expected[i] {
+				t.Errorf("Expected %s[%d] to be %v but was %v", fieldName, i, expected[i], actual[i])
+			}
+		}
+	}
+}
+
+func TestNumberTypes(t *testing.T) {
+	jsonString := `
+{
+  "data": [
+    { "id": 9223372036854775807, "counter": 18446744073709551615, "value": 1.5, "big": "9007199254740993" },
+    { "id": 1, "counter": 2, "value": 2, "big": null }
+  ]
+}
+`
+	frames, err := parseTestData(t, jsonString, querymodel.ParsingOption{
+		DataPath:     "data",
+		NumberType:   querymodel.AUTO,
+		NumberFields: []querymodel.NumberField{{FieldPath: "big", NumberType: querymodel.INT64, ParseStrings: true}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := frames[0]
+	for fieldName, expectedType := range map[string]data.FieldType{
+		"id":      data.FieldTypeNullableInt64,
+		"counter": data.FieldTypeNullableUint64,
+		"value":   data.FieldTypeNullableFloat64,
+		"big":     data.FieldTypeNullableInt64,
+	} {
+		field, _ := frame.FieldByName(fieldName)
+		if field == nil || field.Type() != expectedType {
+			t.Errorf("Expected field %s to have type %v", fieldName, expectedType)
+		}
+	}
+	if id, _ := frame.Fields[0].ConcreteAt(0); id != int64(9223372036854775807) {
+		t.Errorf("Unexpected id: %v", id)
+	}
+	if big, _ := frame.Fields[3].ConcreteAt(0); big != int64(9007199254740993) {
+		t.Errorf("Unexpected big: %v", big)
+	}
+
+	_, err = parseTestData(t, jsonString, querymodel.ParsingOption{DataPath: "data", NumberType: querymodel.INT64})
+	if err == nil {
+		t.Error("Expected an error when a number does not fit in an int64")
+	}
+	_, err = parseTestData(t, `{"data": [{"big": "abc"}]}`, querymodel.ParsingOption{
+		DataPath:     "data",
+		NumberFields: []querymodel.NumberField{{FieldPath: "big", ParseStrings: true}},
+	})
+	if err == nil {
+		t.Error("Expected an error when a string is not a number")
+	}
+}
+
+func TestNumberScaling(t *testing.T) {
+	milli, cents := 0.001, 0.01
+	offset := -273.0
+	frames, err := parseTestData(t, `{"data": [{"voltage": 12500, "price": "250", "kelvin": 300, "count": 5}, {"voltage": null, "price": "", "kelvin": 0, "count": 6}]}`, querymodel.ParsingOption{
+		DataPath: "data",
+		NumberFields: []querymodel.NumberField{
+			{FieldPath: "voltage", Scale: &milli},
+			{FieldPath: "price", Scale: &cents, ParseStrings: true},
+			{FieldPath: "kelvin", Offset: &offset},
+			{FieldPath: "count", Scale: &cents, NumberType: querymodel.AUTO},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFieldValues(t, frames[0], map[string][]any{
+		"voltage": {12.5, nil},
+		"price":   {2.5, nil},
+		"kelvin":  {27.0, -273.0},
+		"count":   {0.05, 0.06},
+	})
+
+	one, scale := 1.0, 10.0
+	frames, err = parseTestData(t, `{"data": [{"id": 9007199254740993, "total": 1844674407370955161}]}`, querymodel.ParsingOption{
+		DataPath: "data",
+		NumberFields: []querymodel.NumberField{
+			{FieldPath: "id", Offset: &one, NumberType: querymodel.INT64},
+			{FieldPath: "total", Scale: &scale, NumberType: querymodel.UINT64},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Neither value can be represented exactly by a float64
+	assertFieldValues(t, frames[0], map[string][]any{
+		"id":    {int64(9007199254740994)},
+		"total": {uint64(18446744073709551610)},
+	})
+
+	_, err = parseTestData(t, `{"data": [{"count": 5}]}`, querymodel.ParsingOption{
+		DataPath:     "data",
+		NumberFields: []querymodel.NumberField{{FieldPath: "count", Scale: &milli}},
+		FieldTypes:   []querymodel.FieldTypeHint{{FieldPath: "count", Type: querymodel.INT}},
+	})
+	if err == nil {
+		t.Error("Expected an error when a scaled value is not an integer")
+	}
+}
@@ -3,16 +3,14 @@ package parsing
 import (
 	"errors"
 	"fmt"
-	"github.com/emirpasic/gods/v2/sets"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/parsing/framemap"
 	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
 	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/util/jsonnode"
 	"reflect"
-	"slices"
 	"strconv"
 	"strings"
-	"time"
 )
 
 // the purpose of this file is to parse JSON data with configuration from a ParsingOption
@@ -74,23 +72,19 @@ func getNodeFromDataPath(graphQlResponseData *jsonnode.Object, dataPath string)
 }
 
 // filterKeysForDataFrame filters keys out of the data frame. Most of the keys filtered out can still be used within labels.
-func filterKeysForDataFrame(keys []string, fieldsExcludedFromDataFrame sets.Set[string]) []string {
+func filterKeysForDataFrame(keys []string, filter *fieldFilter) []string {
 	var r []string = nil
 	for _, key := range keys {
 		// The "#" feature is in beta -- basically if a key contains "#", it was created by this plugin itself, and should not be apart of the data frame
-		if !fieldsExcludedFromDataFrame.Contains(key) && !strings.Contains(key, "#") {
+		if filter.includes(key) && !strings.Contains(key, "#") {
 			r = append(r, key)
 		}
 	}
 	return r
 }
 
-func ParseData(graphQlResponseData *jsonnode.Object, parsingOption querymodel.ParsingOption) (data.Frames, ParseDataErrorType, error) {
-	finalData, err := getNodeFromDataPath(graphQlResponseData, parsingOption.DataPath)
-	if err != nil {
-		return nil, FRIENDLY_ERROR, err
-	}
-
+// toDataArray converts a node matched by the data path into the objects that each represent a single row of data (before exploding)
+func toDataArray(finalData jsonnode.Node, dataPath string) ([]*jsonnode.Object, ParseDataErrorType, error) {
 	var dataArray []*jsonnode.Object
 	switch value := finalData.(type) {
 	case *jsonnode.Array:
@@ -123,94 +117,182 @@ func ParseData(graphQlResponseData *jsonnode.Object, parsingOption querymodel.Pa
 			object,
 		}
 	default:
-		return nil, FRIENDLY_ERROR, fmt.Errorf("final part of data path: is not an array or object! dataPath: %s type of result: %v", parsingOption.DataPath, reflect.TypeOf(value))
+		return nil, FRIENDLY_ERROR, fmt.Errorf("final part of data path: is not an array or object! dataPath: %s type of result: %v", dataPath, reflect.TypeOf(value))
+	}
+	return dataArray, NO_ERROR, nil
+}
+
+// ParseData parses the GraphQL response data into data frames using the configuration of parsingOption.
+// query is the query being executed, which provides the time range among other things.
+func ParseData(graphQlResponseData *jsonnode.Object, parsingOption querymodel.ParsingOption, query backend.DataQuery) (data.Frames, ParseDataErrorType, error) {
+	matchedNodes, err := getNodesFromDataPath(graphQlResponseData, parsingOption.DataPath)
+	if err != nil {
+		return nil, FRIENDLY_ERROR, err
+	}
+
+	// When a data path expression matches multiple nodes, the data from each node is concatenated together
+	var dataArray []*jsonnode.Object
+	for _, matchedNode := range matchedNodes {
+		matchedDataArray, errorType, err := toDataArray(matchedNode, parsingOption.DataPath)
+		if err != nil {
+			return nil, errorType, err
+		}
+		dataArray = append(dataArray, matchedDataArray...)
 	}
 
 	// We store a fieldMap inside of this frameMap.
 	//   fieldMap is a map of keys to array of data points. Upon first initialization of a particular key's value,
 	//   an array should be chosen corresponding to the first value of that key.
-	//   Upon subsequent element insertion, if the type of the array does not match that elements type, an error is thrown.
-	//   This error is never expected to occur because a correct GraphQL response should never have a particular field be of different types
+	//   If the values of a particular key have different types, the mixed type policy decides whether they are promoted to a common type or an error is returned.
+	//   A correct GraphQL response should rarely have a particular field be of different types, but union types and custom scalars make it possible
 	fm := framemap.New()
+	switch parsingOption.MixedTypePolicy {
+	case querymodel.MIXED_TYPE_ERROR, "":
+		fm.SetMixedTypeHandling(framemap.MIXED_TYPES_AS_ERROR)
+	case querymodel.PROMOTE_TO_STRING:
+		fm.SetMixedTypeHandling(framemap.MIXED_TYPES_AS_STRING)
+	case querymodel.PROMOTE_TO_JSON:
+		fm.SetMixedTypeHandling(framemap.MIXED_TYPES_AS_JSON)
+	default:
+		return nil, FRIENDLY_ERROR, fmt.Errorf("unknown mixed type policy: %s", parsingOption.MixedTypePolicy)
+	}
 
-	fieldsExcludedFromDataFrame := parsingOption.GetFieldsExcludedFromDataFrame()
+	options, err := newFlattenOptions(parsingOption)
+	if err != nil {
+		return nil, FRIENDLY_ERROR, err
+	}
 
-	expandedExplodeArrayPaths := expandPathsToSubPaths(parsingOption.ExplodeArrayPaths)
+	filter, err := newFieldFilter(parsingOption, options.keys.getSeparator())
+	if err != nil {
+		return nil, FRIENDLY_ERROR, err
+	}
+
+	converter, err := newFieldConverter(&parsingOption)
+	if err != nil {
+		return nil, FRIENDLY_ERROR, err
+	}
 
+	var flatDataArray []*jsonnode.Object
 	for _, dataElement := range dataArray {
-		flatDataExplodedArray := flattenAndExplode(dataElement, "", expandedExplodeArrayPaths)
+		flatDataExplodedArray, err := flattenAndExplode(dataElement, "", options)
+		if err != nil {
+			return nil, FRIENDLY_ERROR, err
+		}
+		if err := options.checkRowLimit(len(flatDataExplodedArray), entriesPerRow(flatDataExplodedArray), nil); err != nil {
+			return nil, FRIENDLY_ERROR, err
+		}
+		options.producedRows += len(flatDataExplodedArray)
+		flatDataArray = append(flatDataArray, flatDataExplodedArray...)
+	}
 
-		for _, flatData := range flatDataExplodedArray {
-			labels, err := getLabelsFromFlatData(flatData, parsingOption)
+	computedFields, err := compileComputedFields(parsingOption.ComputedFields)
+	if err != nil {
+		return nil, FRIENDLY_ERROR, err
+	}
+	rowFilter, err := compileRowFilter(parsingOption.RowFilter)
+	if err != nil {
+		return nil, FRIENDLY_ERROR, err
+	}
+	if len(computedFields) > 0 || rowFilter != nil {
+		var filteredFlatDataArray []*jsonnode.Object
+		for rowIndex, flatData := range flatDataArray {
+			environment, err := applyComputedFields(flatData, computedFields, options.keys)
 			if err != nil {
-				return nil, FRIENDLY_ERROR, err // getLabelsFromFlatData must always return a friendly error
+				return nil, FRIENDLY_ERROR, fmt.Errorf("row %d: %w", rowIndex, err)
 			}
-			filteredKeys := filterKeysForDataFrame(flatData.Keys(), fieldsExcludedFromDataFrame)
-			row := fm.NewRow(labels)
-			row.FieldOrder = filteredKeys
-
-			for _, key := range filteredKeys {
-				value := flatData.Get(key)
-
-				timeField := parsingOption.GetTimeField(key)
-				if timeField != nil {
-					var timePointer *time.Time
-					switch typedValue := value.(type) {
-					case jsonnode.String:
-						// TODO allow user to customize time format
-						// Look at https://stackoverflow.com/questions/522251/whats-the-difference-between-iso-8601-and-rfc-3339-date-formats
-						//   and also consider using time.RFC339Nano instead
-						parsedTime, err := time.Parse(time.RFC3339, typedValue.String())
-						if err != nil {
-							return nil, FRIENDLY_ERROR, fmt.Errorf("time could not be parsed! Time: %s", typedValue)
-						}
-						timePointer = &parsedTime
-					case jsonnode.Number:
-						epochMillis, err := typedValue.Int64()
-						if err != nil {
-							return nil, UNKNOWN_ERROR, err
-						}
-						t := time.UnixMilli(epochMillis)
-						timePointer = &t
-					case jsonnode.Null:
-						timePointer = nil
-					default:
-						// This case should never happen because we never expect other types to pop up here
-						return nil, FRIENDLY_ERROR, fmt.Errorf("unsupported time type! Time: %s type: %v", typedValue, reflect.TypeOf(typedValue))
-					}
-					if timePointer == nil {
-						row.FieldMap[key] = jsonnode.NULL
-					} else {
-						row.FieldMap[key] = *timePointer
-					}
-				} else {
-					switch typedValue := value.(type) {
-					case jsonnode.String:
-						row.FieldMap[key] = typedValue.String()
-					case jsonnode.Boolean:
-						row.FieldMap[key] = typedValue.Bool()
-					case jsonnode.Number:
-						parsedValue, err := typedValue.Float64()
-						if err != nil {
-							return nil, UNKNOWN_ERROR, fmt.Errorf("could not parse number: %s", typedValue.String())
-						}
-						row.FieldMap[key] = parsedValue
-						// NOTE: We are allowed to store a jsonnode.Number type directly into the FieldMap (it's part of the contract to support that),
-						//   but we decide not to because alerting queries require float64s to be used
-					case jsonnode.Null:
-						row.FieldMap[key] = typedValue
-					default:
-						return nil, UNKNOWN_ERROR, fmt.Errorf("unsupported type! type: %v", reflect.TypeOf(typedValue))
-					}
+			if rowFilter != nil {
+				matches, err := rowFilter.matches(flatData, environment, options.keys)
+				if err != nil {
+					return nil, FRIENDLY_ERROR, fmt.Errorf("row %d: %w", rowIndex, err)
+				}
+				if !matches {
+					continue
+				}
+			}
+			filteredFlatDataArray = append(filteredFlatDataArray, flatData)
+		}
+		flatDataArray = filteredFlatDataArray
+	}
+
+	notices := computedFieldNotices(computedFields)
+	var detectedKeys []string
+	if parsingOption.AutoDetectTimeFields {
+		detectedKeys = detectTimeFields(flatDataArray, &parsingOption, query.TimeRange, converter.timeParsers, filter, options.keys.getSeparator())
+		notices = append(notices, timeFieldDetectionNotice(detectedKeys))
+	}
+
+	for rowIndex, flatData := range flatDataArray {
+		labels, err := getLabelsFromFlatData(flatData, parsingOption)
+		if err != nil {
+			return nil, FRIENDLY_ERROR, err // getLabelsFromFlatData must always return a friendly error
+		}
+		indexes, err := explodeIndexes(flatData, parsingOption, options)
+		if err != nil {
+			return nil, FRIENDLY_ERROR, fmt.Errorf("row %d: %w", rowIndex, err)
+		}
+		if parsingOption.ExplodeIndexMode == querymodel.INDEX_AS_LABEL {
+			for _, index := range indexes {
+				labels[index.key] = strconv.Itoa(index.value)
+			}
+		}
+		filteredKeys := filterKeysForDataFrame(flatData.Keys(), filter)
+		row := fm.NewRow(labels)
+		row.FieldOrder = filteredKeys
+		if parsingOption.ExplodeIndexMode == querymodel.INDEX_AS_FIELD {
+			for _, index := range indexes {
+				row.FieldOrder = append(row.FieldOrder, index.key)
+				row.FieldMap[index.key] = int64(index.value)
+			}
+		}
+
+		for _, key := range filteredKeys {
+			value, errorType, err := converter.convert(key, flatData.Get(key))
+			if err != nil {
+				if errorType == FRIENDLY_ERROR {
+					return nil, errorType, fmt.Errorf("row %d: %w", rowIndex, err)
 				}
+				return nil, errorType, err
 			}
+			row.FieldMap[key] = value
 		}
 	}
 
+	if err := addDerivedFields(fm, parsingOption, detectedKeys); err != nil {
+		return nil, FRIENDLY_ERROR, err
+	}
+	if err := setDownsampling(fm, parsingOption, detectedKeys, query); err != nil {
+		return nil, FRIENDLY_ERROR, err
+	}
+	if err := setRowOrder(fm, parsingOption, detectedKeys); err != nil {
+		return nil, FRIENDLY_ERROR, err
+	}
+	if err := setSeriesLimit(fm, parsingOption, detectedKeys); err != nil {
+		return nil, FRIENDLY_ERROR, err
+	}
+	if err := setFieldSettings(fm, parsingOption); err != nil {
+		return nil, FRIENDLY_ERROR, err
+	}
+	if parsingOption.NameTemplate != "" {
+		fm.SetNameFunc(func(labels data.Labels, fieldName string) string {
+			return renderNameTemplate(parsingOption.NameTemplate, labels, fieldName, query.RefID)
+		})
+	}
+
 	frames, err := fm.ToFrames()
 	if err != nil {
+		var mixedTypeError *framemap.MixedTypeError
+		if errors.As(err, &mixedTypeError) {
+			return nil, FRIENDLY_ERROR, fmt.Errorf("%w. Consider changing the mixed type policy", err)
+		}
+		var fieldNameCollisionError *framemap.FieldNameCollisionError
+		if errors.As(err, &fieldNameCollisionError) {
+			return nil, FRIENDLY_ERROR, fmt.Errorf("%w. Consider changing the alias of the field", err)
+		}
 		return nil, UNKNOWN_ERROR, err
 	}
+	for _, frame := range frames {
+		frame.AppendNotices(notices...)
+	}
 	return frames, NO_ERROR, nil
 }
 
@@ -238,6 +320,9 @@ func getLabelsFromFlatData(flatData *jsonnode.Object, parsingOption querymodel.P
 				case jsonnode.Number:
 					// TODO decide if we want to "normalize" when converting to string -- should 5.0 and 5 be the same string value?
 					labels[labelOption.Name] = typedFieldValue.String()
+				case *jsonnode.Array, *jsonnode.Object:
+					// Only present when kept as JSON rather than flattened
+					labels[labelOption.Name] = string(typedFieldValue.Serialize())
 				default:
 					return nil, fmt.Errorf("label option: %s could not be satisfied as key %s is not a string. It's type is %v", labelOption.Name, labelOption.Value, reflect.TypeOf(typedFieldValue))
 				}
@@ -247,14 +332,22 @@ func getLabelsFromFlatData(flatData *jsonnode.Object, parsingOption querymodel.P
 	return labels, nil
 }
 
-func flattenArray(array *jsonnode.Array, prefix string, flattenedData *jsonnode.Object) {
+func flattenArray(array *jsonnode.Array, arrayKey string, flattenedData *jsonnode.Object, options *flattenOptions) {
 	for key, value := range *array {
-		baseKey := fmt.Sprintf("%s%d", prefix, key)
+		baseKey := options.keys.indexKey(arrayKey, key)
 		switch typedValue := value.(type) {
 		case *jsonnode.Object:
-			flattenData(typedValue, baseKey+".", flattenedData)
+			if collapsed := options.collapseNested(baseKey, typedValue, false); collapsed != nil {
+				flattenedData.Put(baseKey, collapsed)
+			} else {
+				flattenData(typedValue, options.keys.prefixOf(baseKey), flattenedData, options)
+			}
 		case *jsonnode.Array:
-			flattenArray(typedValue, baseKey+".", flattenedData)
+			if collapsed := options.collapseNested(baseKey, typedValue, false); collapsed != nil {
+				flattenedData.Put(baseKey, collapsed)
+			} else {
+				flattenArray(typedValue, baseKey, flattenedData, options)
+			}
 		default:
 			flattenedData.Put(
 				baseKey,
@@ -263,21 +356,27 @@ func flattenArray(array *jsonnode.Array, prefix string, flattenedData *jsonnode.
 		}
 	}
 }
-
-func flattenData(originalData *jsonnode.Object, prefix string, flattenedData *jsonnode.Object) {
+func flattenData(originalData *jsonnode.Object, prefix string, flattenedData *jsonnode.Object, options *flattenOptions) {
 	for _, key := range originalData.Keys() {
 		value := originalData.Get(key)
 		switch typedValue := value.(type) {
 		case *jsonnode.Object:
-			flattenData(typedValue, prefix+key+".", flattenedData)
+			if collapsed := options.collapseNested(prefix+key, typedValue, false); collapsed != nil {
+				flattenedData.Put(prefix+key, collapsed)
+			} else {
+				flattenData(typedValue, options.keys.prefixOf(prefix+key), flattenedData, options)
+			}
 		case *jsonnode.Array:
-			flattenArray(typedValue, prefix+key+".", flattenedData)
+			if collapsed := options.collapseNested(prefix+key, typedValue, false); collapsed != nil {
+				flattenedData.Put(prefix+key, collapsed)
+			} else {
+				flattenArray(typedValue, prefix+key, flattenedData, options)
+			}
 		default:
 			flattenedData.Put(prefix+key, typedValue)
 		}
 	}
 }
-
 func crossObjects(a []*jsonnode.Object, b []*jsonnode.Object) []*jsonnode.Object {
 	slice := make([]*jsonnode.Object, len(a)*len(b))
 	for i, bObject := range b {
@@ -291,86 +390,386 @@ func crossObjects(a []*jsonnode.Object, b []*jsonnode.Object) []*jsonnode.Object
 	}
 	return slice
 }
-func explodeArray(data []*jsonnode.Object, nestedArrayFullKey string, explodeDataPaths []string, nestedArray *jsonnode.Array) []*jsonnode.Object {
+func explodeArray(data []*jsonnode.Object, nestedArrayFullKey string, options *flattenOptions, nestedArray *jsonnode.Array) ([]*jsonnode.Object, error) {
 	var r []*jsonnode.Object
 	for index, nestedArrayElement := range *nestedArray {
 		for _, dataObject := range data {
 			switch typedValue := nestedArrayElement.(type) {
 			case *jsonnode.Object:
-				result := flattenAndExplode(typedValue, nestedArrayFullKey+".", explodeDataPaths)
+				result, err := flattenAndExplode(typedValue, options.keys.prefixOf(nestedArrayFullKey), options)
+				if err != nil {
+					return nil, err
+				}
+				options.putElementIndex(result, nestedArrayFullKey, index)
+				if err := options.checkRowLimit(len(r)+len(result), len(dataObject.Keys())+entriesPerRow(result), []string{nestedArrayFullKey}); err != nil {
+					return nil, err
+				}
 				resultCrossed := crossObjects([]*jsonnode.Object{dataObject}, result)
 				r = append(r, resultCrossed...)
 			case *jsonnode.Array:
-				innerArrayFullKey := nestedArrayFullKey + "._" // The "._" suffix is something we made up
-				if slices.Contains(explodeDataPaths, innerArrayFullKey) {
-					result := explodeArray([]*jsonnode.Object{jsonnode.NewObject()}, innerArrayFullKey, explodeDataPaths, typedValue)
+				innerArrayFullKey := options.keys.nestedArrayKey(nestedArrayFullKey)
+				if options.shouldExplode(innerArrayFullKey, typedValue) {
+					result, err := explodeArray([]*jsonnode.Object{jsonnode.NewObject()}, innerArrayFullKey, options, typedValue)
+					if err != nil {
+						return nil, err
+					}
+					options.putElementIndex(result, nestedArrayFullKey, index)
+					if err := options.checkRowLimit(len(r)+len(result), len(dataObject.Keys())+entriesPerRow(result), []string{innerArrayFullKey}); err != nil {
+						return nil, err
+					}
 					resultCrossed := crossObjects([]*jsonnode.Object{dataObject}, result)
 					r = append(r, resultCrossed...)
+				} else if collapsed := options.collapseNested(innerArrayFullKey, typedValue, false); collapsed != nil {
+					newObject := dataObject.Clone()
+					newObject.Put(innerArrayFullKey, collapsed)
+					options.putElementIndex([]*jsonnode.Object{newObject}, nestedArrayFullKey, index)
+					r = append(r, newObject)
 				} else {
 					flattenedData := jsonnode.NewObject()
-					flattenArray(typedValue, innerArrayFullKey+".", flattenedData)
+					flattenArray(typedValue, innerArrayFullKey, flattenedData, options)
 					newObject := dataObject.Clone()
 					newObject.PutFrom(flattenedData)
+					options.putElementIndex([]*jsonnode.Object{newObject}, nestedArrayFullKey, index)
 					r = append(r, newObject)
 				}
 			default:
 				newObject := dataObject.Clone()
 				newObject.Put(nestedArrayFullKey, typedValue)
-				// Use jsonnode.String here because this will only ever be used as a label's value, which must be a string
-				// Note that this feature is in beta. Because the key includes "#" it will not be included in the data frame
-				// It might be worth adding some sort of setting in the future to enable/disable additional fields like this to be populated by this plugin
-				newObject.Put(nestedArrayFullKey+"#index", jsonnode.String(fmt.Sprintf("%d", index)))
+				options.putExplodeIndex([]*jsonnode.Object{newObject}, nestedArrayFullKey, index)
 				r = append(r, newObject)
 			}
 		}
 	}
-	return r
+	return r, nil
 }
 
-func expandPathsToSubPaths(paths []string) []string {
+// putExplodeIndex puts the index of an exploded array element into each of the objects created from that element.
+// The key holding the index is recorded in options.explodeIndexKeys.
+func (options *flattenOptions) putExplodeIndex(objects []*jsonnode.Object, nestedArrayFullKey string, index int) {
+	if options.explodeIndexKeys == nil {
+		options.explodeIndexKeys = map[string]bool{}
+	}
+	options.explodeIndexKeys[nestedArrayFullKey+EXPLODE_INDEX_SUFFIX] = true
+	for _, object := range objects {
+		// Use jsonnode.String here because this is most often used as a label's value, which must be a string
+		// Because the key includes "#" it will not be included in the data frame unless the index is exposed as a field
+		object.Put(nestedArrayFullKey+EXPLODE_INDEX_SUFFIX, jsonnode.String(strconv.Itoa(index)))
+	}
+}
+
+// putElementIndex puts the index of an exploded object or array element into each of the objects created from that element.
+// Unlike the index of a scalar element, this is only done when the index is exposed, so that the flattened keys are otherwise unchanged.
+func (options *flattenOptions) putElementIndex(objects []*jsonnode.Object, nestedArrayFullKey string, index int) {
+	if options.explodeIndexes {
+		options.putExplodeIndex(objects, nestedArrayFullKey, index)
+	}
+}
+
+// zipObjects combines the exploded rows of sibling arrays index by index.
+// Each element of explodedArrays must have the same length, otherwise a friendly error is returned.
+func zipObjects(keys []string, explodedArrays [][]*jsonnode.Object) ([]*jsonnode.Object, error) {
+	length := len(explodedArrays[0])
+	for i, explodedArray := range explodedArrays {
+		if len(explodedArray) != length {
+			return nil, fmt.Errorf("cannot zip exploded arrays of different lengths! %s has %d rows but %s has %d rows", keys[0], length, keys[i], len(explodedArray))
+		}
+	}
+	r := make([]*jsonnode.Object, length)
+	for i := range r {
+		newObject := jsonnode.NewObject()
+		for _, explodedArray := range explodedArrays {
+			newObject.PutFrom(explodedArray[i])
+		}
+		r[i] = newObject
+	}
+	return r, nil
+}
+
+// expandPathsToSubPaths returns paths along with each of their parent paths that do not contain a wildcard.
+// Parent paths that contain a wildcard are instead returned by wildcardParentPaths, as they are only exploded when they lead to an exploded array.
+func expandPathsToSubPaths(paths []string, separator string) []string {
 	var r []string = nil
 	for _, path := range paths {
 		r = append(r, path)
 		var subPath = path
 		for {
-			lastIndex := strings.LastIndex(subPath, ".")
+			lastIndex := strings.LastIndex(subPath, separator)
 			if lastIndex < 0 {
 				break
 			}
 			subPath = subPath[:lastIndex]
-			r = append(r, subPath)
+			if !isExplodePathPattern(subPath) {
+				r = append(r, subPath)
+			}
 		}
-		strings.Split(path, ".")
 	}
 	return r
 }
 
-// flattenAndExplode will recursively flatten data and explode nested arrays if their path is contained within the explodeDataPaths argument.
+// wildcardParentPaths returns the parent paths of paths that contain a wildcard, such as "devices.*" for "devices.*.readings"
+func wildcardParentPaths(paths []string, separator string) []string {
+	var r []string = nil
+	for _, path := range paths {
+		var subPath = path
+		for {
+			lastIndex := strings.LastIndex(subPath, separator)
+			if lastIndex < 0 {
+				break
+			}
+			subPath = subPath[:lastIndex]
+			if isExplodePathPattern(subPath) {
+				r = append(r, subPath)
+			}
+		}
+	}
+	return r
+}
+
+// flattenOptions holds the configuration used by flattenAndExplode and explodeArray
+type flattenOptions struct {
+	// explodeDataPaths is an array of data paths that point to a nested array. These paths may contain wildcards (see matchesExplodePath).
+	// It is recommended to manually expand all paths into their subpaths as well, as flattenAndExplode does not check that super-paths are contained within explodeDataPaths.
+	explodeDataPaths []string
+	// wildcardParentPaths are parent paths of explodeDataPaths that contain a wildcard (see wildcardParentPaths).
+	// A nested object or array matching one of these is only exploded if it contains an array matching explodeDataPaths.
+	wildcardParentPaths []string
+	// zip is true when sibling arrays that are exploded should be combined index by index, rather than by taking their Cartesian product
+	zip bool
+	// maxRows is the maximum number of rows that may be produced for a single parsing option. A value of 0 means there is no limit.
+	maxRows int
+	// producedRows is the number of rows produced by data elements that have already been flattened
+	producedRows int
+	// keepJsonPaths is an array of paths (which may contain wildcards) to nested objects and arrays that are kept as a single JSON value rather than being flattened
+	keepJsonPaths []string
+	// flattenDepth is the number of levels of nested objects and arrays that are flattened, or nil if there is no limit. Deeper values are kept as JSON.
+	flattenDepth *int
+	keys         keyFormat
+	// scalarArrayMode determines whether arrays of scalars are flattened or combined into a single value. A blank value flattens them
+	scalarArrayMode      querymodel.ScalarArrayMode
+	scalarArrayDelimiter string
+	// explodeIndexes is true when the index of each exploded object or array element is exposed as a field or a label
+	explodeIndexes bool
+	// explodeIndexKeys are the keys that putExplodeIndex has put an index into.
+	//   Other keys may end with EXPLODE_INDEX_SUFFIX, such as those of an object with a key like "x#index"
+	explodeIndexKeys map[string]bool
+}
+
+// newFlattenOptions creates flattenOptions or returns a friendly error if the parsing option is misconfigured
+func newFlattenOptions(parsingOption querymodel.ParsingOption) (*flattenOptions, error) {
+	keys, err := newKeyFormat(parsingOption)
+	if err != nil {
+		return nil, err
+	}
+	switch parsingOption.ScalarArrayMode {
+	case querymodel.FLATTEN_SCALARS, querymodel.JOIN_SCALARS, querymodel.SCALARS_AS_JSON, "":
+	default:
+		return nil, fmt.Errorf("unknown scalar array mode: %s", parsingOption.ScalarArrayMode)
+	}
+	switch parsingOption.ExplodeMode {
+	case querymodel.CROSS, querymodel.ZIP, "":
+	default:
+		return nil, fmt.Errorf("unknown explode mode: %s", parsingOption.ExplodeMode)
+	}
+	switch parsingOption.ExplodeIndexMode {
+	case querymodel.NO_INDEX, querymodel.INDEX_AS_FIELD, querymodel.INDEX_AS_LABEL, "":
+	default:
+		return nil, fmt.Errorf("unknown explode index mode: %s", parsingOption.ExplodeIndexMode)
+	}
+	scalarArrayDelimiter := DEFAULT_SCALAR_ARRAY_DELIMITER
+	if parsingOption.ScalarArrayDelimiter != nil {
+		scalarArrayDelimiter = *parsingOption.ScalarArrayDelimiter
+	}
+	maxRows := DEFAULT_MAX_ROWS
+	if parsingOption.MaxRows != nil && *parsingOption.MaxRows > 0 {
+		maxRows = *parsingOption.MaxRows
+	}
+	return &flattenOptions{
+		explodeDataPaths:     expandPathsToSubPaths(parsingOption.ExplodeArrayPaths, keys.getSeparator()),
+		wildcardParentPaths:  wildcardParentPaths(parsingOption.ExplodeArrayPaths, keys.getSeparator()),
+		zip:                  parsingOption.ExplodeMode == querymodel.ZIP,
+		maxRows:              maxRows,
+		keepJsonPaths:        parsingOption.KeepJsonPaths,
+		flattenDepth:         parsingOption.FlattenDepth,
+		keys:                 keys,
+		scalarArrayMode:      parsingOption.ScalarArrayMode,
+		scalarArrayDelimiter: scalarArrayDelimiter,
+		explodeIndexes:       parsingOption.ExplodeIndexMode == querymodel.INDEX_AS_FIELD || parsingOption.ExplodeIndexMode == querymodel.INDEX_AS_LABEL,
+	}, nil
+}
+
+// shouldExplode determines if the nested object or array at fullKey is (or leads to) an exploded array
+func (options *flattenOptions) shouldExplode(fullKey string, value jsonnode.Node) bool {
+	if matchesExplodePath(options.explodeDataPaths, fullKey, options.keys.getSeparator()) {
+		return true
+	}
+	return matchesExplodePath(options.wildcardParentPaths, fullKey, options.keys.getSeparator()) && options.containsExplodedArray(fullKey, value)
+}
+
+// containsExplodedArray determines if the nested object or array at fullKey contains an array that matches explodeDataPaths.
+// The keys of nested values are the keys they have when the arrays leading to them are exploded.
+func (options *flattenOptions) containsExplodedArray(fullKey string, value jsonnode.Node) bool {
+	switch typedValue := value.(type) {
+	case *jsonnode.Object:
+		for _, key := range typedValue.Keys() {
+			childKey := options.keys.prefixOf(fullKey) + key
+			child := typedValue.Get(key)
+			if _, isArray := child.(*jsonnode.Array); isArray && matchesExplodePath(options.explodeDataPaths, childKey, options.keys.getSeparator()) {
+				return true
+			}
+			if options.containsExplodedArray(childKey, child) {
+				return true
+			}
+		}
+	case *jsonnode.Array:
+		for _, element := range *typedValue {
+			switch element.(type) {
+			case *jsonnode.Object:
+				if options.containsExplodedArray(fullKey, element) {
+					return true
+				}
+			case *jsonnode.Array:
+				innerArrayFullKey := options.keys.nestedArrayKey(fullKey)
+				if matchesExplodePath(options.explodeDataPaths, innerArrayFullKey, options.keys.getSeparator()) || options.containsExplodedArray(innerArrayFullKey, element) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// keepAsJson determines if the nested object or array at fullKey should be kept as a single JSON value rather than being flattened.
+// explode should be true when fullKey is (or leads to) an exploded array, in which case the flatten depth is ignored.
+func (options *flattenOptions) keepAsJson(fullKey string, explode bool) bool {
+	if matchesExplodePath(options.keepJsonPaths, fullKey, options.keys.getSeparator()) {
+		return true
+	}
+	return !explode && options.flattenDepth != nil && options.keys.depthOf(fullKey) >= *options.flattenDepth
+}
+
+// collapseNested returns the single value that the nested object or array at fullKey is replaced with rather than being flattened,
+// or nil if it should be flattened. explode should be true when fullKey is (or leads to) an exploded array.
+func (options *flattenOptions) collapseNested(fullKey string, value jsonnode.Node, explode bool) jsonnode.Node {
+	if options.keepAsJson(fullKey, explode) {
+		return value
+	}
+	if array, isArray := value.(*jsonnode.Array); isArray && !explode && isScalarArray(array) {
+		switch options.scalarArrayMode {
+		case querymodel.JOIN_SCALARS:
+			return jsonnode.String(joinScalarArray(array, options.scalarArrayDelimiter))
+		case querymodel.SCALARS_AS_JSON:
+			return array
+		}
+	}
+	return nil
+}
+
+const (
+	// DEFAULT_MAX_ROWS is the maximum number of rows a parsing option may produce when querymodel.ParsingOption.MaxRows is not set
+	DEFAULT_MAX_ROWS = 1_000_000
+	// maxEstimatedMemoryBytes is the maximum amount of memory we allow a single cross product to use.
+	//   Each cross product clones every entry of the objects being crossed, so exploding multiple large sibling arrays can quickly run the plugin out of memory.
+	maxEstimatedMemoryBytes = 512 * 1024 * 1024
+	// estimatedBytesPerEntry is a rough estimate of the memory used by a single key of a jsonnode.Object,
+	//   which includes the map entry, the key within the order slice, and the Node interface value.
+	estimatedBytesPerEntry = 128
+)
+
+// checkRowLimit returns a friendly error if producing rowCount rows, each with roughly entriesPerRow entries, is more than the plugin should handle.
+// paths are the exploded paths responsible for the rows, which are included in the error message.
+func (options *flattenOptions) checkRowLimit(rowCount int, entriesPerRow int, paths []string) error {
+	totalRows := options.producedRows + rowCount
+	if options.maxRows > 0 && totalRows > options.maxRows {
+		return fmt.Errorf("exploding %s would result in %d rows, which is more than the maximum of %d rows! Consider exploding fewer arrays, using the zip explode mode, or increasing the maximum number of rows", describePaths(paths), totalRows, options.maxRows)
+	}
+	estimatedBytes := int64(rowCount) * int64(entriesPerRow) * estimatedBytesPerEntry
+	if estimatedBytes > maxEstimatedMemoryBytes {
+		return fmt.Errorf("exploding %s would result in %d rows using an estimated %d MiB of memory, which is more than the maximum of %d MiB! Consider exploding fewer arrays or using the zip explode mode", describePaths(paths), rowCount, estimatedBytes/(1024*1024), maxEstimatedMemoryBytes/(1024*1024))
+	}
+	return nil
+}
+
+func describePaths(paths []string) string {
+	if len(paths) == 0 {
+		return "the data"
+	}
+	return "paths [" + strings.Join(paths, ", ") + "]"
+}
+
+// entriesPerRow returns the number of entries in the first row, which is used as an estimate of the number of entries in every row
+func entriesPerRow(rows []*jsonnode.Object) int {
+	if len(rows) == 0 {
+		return 0
+	}
+	return len(rows[0].Keys())
+}
+
+// flattenAndExplode will recursively flatten data and explode nested arrays if their path is contained within options.explodeDataPaths.
 //
 // data is the source object to flatten.
 // prefix is used to prefix the field names on each returned jsonnode.Object.
-// explodeDataPaths is an array of data paths that point to a nested array.
-// It is recommended to manually expand all paths into their subpaths as well, as this function does not check that super-paths are contained within explodeDataPaths.
-func flattenAndExplode(data *jsonnode.Object, prefix string, explodeDataPaths []string) []*jsonnode.Object {
+// Any error returned is a friendly error caused by the configuration or the data.
+func flattenAndExplode(data *jsonnode.Object, prefix string, options *flattenOptions) ([]*jsonnode.Object, error) {
 	var r = []*jsonnode.Object{
 		jsonnode.NewObject(),
 	}
+	// When zipping, exploded sibling arrays are collected here and combined after every other key has been processed
+	var zipKeys []string
+	var zipExplodedArrays [][]*jsonnode.Object
+	// The paths that have multiplied the number of rows, which are used to give a helpful error message
+	var explodedPaths []string
 	for _, key := range data.Keys() {
 		value := data.Get(key)
 		fullKey := prefix + key
+		switch value.(type) {
+		case *jsonnode.Object, *jsonnode.Array:
+			if collapsed := options.collapseNested(fullKey, value, options.shouldExplode(fullKey, value)); collapsed != nil {
+				for _, object := range r {
+					object.Put(fullKey, collapsed)
+				}
+				continue
+			}
+		}
 		switch typedValue := value.(type) {
 		case *jsonnode.Object:
-			nestedDataArray := flattenAndExplode(typedValue, fullKey+".", explodeDataPaths)
+			nestedDataArray, err := flattenAndExplode(typedValue, options.keys.prefixOf(fullKey), options)
+			if err != nil {
+				return nil, err
+			}
+			if len(nestedDataArray) > 1 {
+				explodedPaths = append(explodedPaths, fullKey)
+				if err := options.checkRowLimit(len(r)*len(nestedDataArray), entriesPerRow(r)+entriesPerRow(nestedDataArray), explodedPaths); err != nil {
+					return nil, err
+				}
+			}
 			r = crossObjects(r, nestedDataArray)
 		case *jsonnode.Array:
-			if slices.Contains(explodeDataPaths, fullKey) {
+			if options.shouldExplode(fullKey, typedValue) {
 				// Note that if value is an empty array, explodeArray() will return an empty array
 				//   This could cause confusion when someone gets back an empty or mostly empty dataframe,
 				//   but this is intended behavior.
-				r = explodeArray(r, fullKey, explodeDataPaths, typedValue)
+				if options.zip {
+					explodedArray, err := explodeArray([]*jsonnode.Object{jsonnode.NewObject()}, fullKey, options, typedValue)
+					if err != nil {
+						return nil, err
+					}
+					zipKeys = append(zipKeys, fullKey)
+					zipExplodedArrays = append(zipExplodedArrays, explodedArray)
+				} else {
+					explodedPaths = append(explodedPaths, fullKey)
+					// This is a lower bound of the resulting number of rows, but it catches most large cross products before they are created
+					if err := options.checkRowLimit(len(r)*len(*typedValue), entriesPerRow(r)+1, explodedPaths); err != nil {
+						return nil, err
+					}
+					var err error
+					r, err = explodeArray(r, fullKey, options, typedValue)
+					if err != nil {
+						return nil, err
+					}
+				}
 			} else {
 				flattenedData := jsonnode.NewObject()
-				flattenArray(typedValue, fullKey+".", flattenedData)
+				flattenArray(typedValue, fullKey, flattenedData, options)
 				for _, object := range r {
 					object.PutFrom(flattenedData)
 				}
@@ -381,5 +780,15 @@ func flattenAndExplode(data *jsonnode.Object, prefix string, explodeDataPaths []
 			}
 		}
 	}
-	return r
+	if len(zipExplodedArrays) > 0 {
+		zipped, err := zipObjects(zipKeys, zipExplodedArrays)
+		if err != nil {
+			return nil, err
+		}
+		if err := options.checkRowLimit(len(r)*len(zipped), entriesPerRow(r)+entriesPerRow(zipped), append(explodedPaths, zipKeys...)); err != nil {
+			return nil, err
+		}
+		r = crossObjects(r, zipped)
+	}
+	return r, nil
 }
@@ -3,9 +3,12 @@ package parsing
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
 	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/util/jsonnode"
 	"reflect"
 	"slices"
+	"strings"
 	"testing"
 )
 
@@ -21,7 +24,10 @@ func TestExplodeArrayWhenGivenArrayOfNumbers(t *testing.T) {
 	if err != nil {
 		t.Fatal("Could not unmarshal JSON", err)
 	}
-	results := explodeArray([]*jsonnode.Object{jsonnode.NewObject()}, "value", nil, array)
+	results, err := explodeArray([]*jsonnode.Object{jsonnode.NewObject()}, "value", &flattenOptions{}, array)
+	if err != nil {
+		t.Fatal(err)
+	}
 	if len(results) != 3 {
 		t.Fatalf("len(results) is unexpected! its value is: %d", len(results))
 	}
@@ -52,7 +58,10 @@ func TestExplodeArrayWhenGivenArrayOfArrays(t *testing.T) {
 	if err != nil {
 		t.Fatal("Could not unmarshal JSON", err)
 	}
-	results := explodeArray([]*jsonnode.Object{jsonnode.NewObject()}, "value", nil, array)
+	results, err := explodeArray([]*jsonnode.Object{jsonnode.NewObject()}, "value", &flattenOptions{}, array)
+	if err != nil {
+		t.Fatal(err)
+	}
 	if len(results) != 3 {
 		t.Fatalf("len(results) is unexpected! its value is: %d", len(results))
 	}
@@ -79,7 +88,7 @@ func TestExpandPathsToSubPaths(t *testing.T) {
 	result1 := expandPathsToSubPaths([]string{
 		"a.b.c",
 		"c.b.a",
-	})
+	}, ".")
 	if !slices.Equal(result1, []string{
 		"a.b.c",
 		"a.b",
@@ -121,11 +130,17 @@ func TestFlattenAndExplode(t *testing.T) {
 	if err != nil {
 		t.Fatal("Could not unmarshal JSON", err)
 	}
-	resultsWithMisconfiguredPaths := flattenAndExplode(object, "", []string{"data.data"})
+	resultsWithMisconfiguredPaths, err := flattenAndExplode(object, "", &flattenOptions{explodeDataPaths: []string{"data.data"}})
+	if err != nil {
+		t.Fatal(err)
+	}
 	if len(resultsWithMisconfiguredPaths) != 1 {
 		t.Errorf("When we misconfigure the explodeDataPaths, we expect a length of 1, but got %d", len(resultsWithMisconfiguredPaths))
 	}
-	results := flattenAndExplode(object, "", []string{"data", "data.data"})
+	results, err := flattenAndExplode(object, "", &flattenOptions{explodeDataPaths: []string{"data", "data.data"}})
+	if err != nil {
+		t.Fatal(err)
+	}
 	if len(results) != 4 {
 		t.Fatalf("Incorrect results size! size is %d", len(results))
 	}
@@ -170,7 +185,10 @@ func TestFlattenAndExplodeAnEmptyArray(t *testing.T) {
 	if err != nil {
 		t.Fatal("Could not unmarshal JSON", err)
 	}
-	results := flattenAndExplode(object, "", []string{"data", "data.data"})
+	results, err := flattenAndExplode(object, "", &flattenOptions{explodeDataPaths: []string{"data", "data.data"}})
+	if err != nil {
+		t.Fatal(err)
+	}
 	if len(results) != 0 {
 		t.Fatalf("Incorrect results size! size is %d", len(results))
 	}
@@ -191,7 +209,10 @@ func TestFlattenAndExplodeWithNestedArrayThatGetsFlattened(t *testing.T) {
 	if err != nil {
 		t.Fatal("Could not unmarshal JSON", err)
 	}
-	results := flattenAndExplode(object, "", []string{"data"})
+	results, err := flattenAndExplode(object, "", &flattenOptions{explodeDataPaths: []string{"data"}})
+	if err != nil {
+		t.Fatal(err)
+	}
 	if len(results) != 2 {
 		t.Fatalf("Incorrect results size! size is %d", len(results))
 	}
@@ -237,7 +258,10 @@ func TestFlattenAndExplodeWithNestedArrayThatGetsExploded(t *testing.T) {
 	if err != nil {
 		t.Fatal("Could not unmarshal JSON", err)
 	}
-	results := flattenAndExplode(object, "", []string{"data", "data._"})
+	results, err := flattenAndExplode(object, "", &flattenOptions{explodeDataPaths: []string{"data", "data._"}})
+	if err != nil {
+		t.Fatal(err)
+	}
 	if len(results) != 4 {
 		t.Fatalf("Incorrect results size! size is %d", len(results))
 	}
@@ -267,3 +291,233 @@ func TestFlattenAndExplodeWithNestedArrayThatGetsExploded(t *testing.T) {
 		}
 	}
 }
+
+func TestMatchesExplodePath(t *testing.T) {
+	explodePaths := expandPathsToSubPaths([]string{"devices.*.readings", "servers.**"}, ".")
+	for key, expected := range map[string]bool{
+		"devices":                     true,
+		"devices.battery":             false,
+		"devices.battery.readings":    true,
+		"devices.battery.history":     false,
+		"devices.battery.a.readings":  false,
+		"servers":                     true,
+		"servers.data":                true,
+		"servers.data._.temperatures": true,
+		"other":                       false,
+	} {
+		if matchesExplodePath(explodePaths, key, ".") != expected {
+			t.Errorf("Expected matchesExplodePath to return %v for key %s", expected, key)
+		}
+	}
+	if parentPaths := wildcardParentPaths([]string{"devices.*.readings", "servers.**"}, "."); !slices.Equal(parentPaths, []string{"devices.*"}) {
+		t.Errorf("Unexpected wildcard parent paths: %#v", parentPaths)
+	}
+	if !matchSegment("battery*", "batteryVoltage") || matchSegment("battery*", "voltage") || !matchSegment("*Voltage", "batteryVoltage") {
+		t.Error("matchSegment did not match correctly")
+	}
+}
+
+func TestFlattenAndExplodeWithWildcard(t *testing.T) {
+	jsonString := `
+{
+  "serverName": "awesome sauce",
+  "data": [
+    {
+      "dateMillis": 1234,
+      "data": [
+        { "processor": 0, "temperature": 30.2 },
+        { "processor": 1, "temperature": 31 }
+      ]
+    },
+    {
+      "dateMillis": 12345,
+      "data": [
+        { "processor": 0, "temperature": 35.2 }
+      ]
+    }
+  ]
+}
+`
+	var object = jsonnode.NewObject()
+	err := json.Unmarshal([]byte(jsonString), &object)
+	if err != nil {
+		t.Fatal("Could not unmarshal JSON", err)
+	}
+	results, err := flattenAndExplode(object, "", &flattenOptions{explodeDataPaths: expandPathsToSubPaths([]string{"data.**"}, ".")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Incorrect results size! size is %d", len(results))
+	}
+	for i, expectedTemperature := range []string{"30.2", "31", "35.2"} {
+		temperatureNode := results[i].Get("data.data.temperature")
+		if temperature, ok := temperatureNode.(jsonnode.Number); !ok || temperature.String() != expectedTemperature {
+			t.Errorf("Element %d had unexpected temperature value: %v", i, temperatureNode)
+		}
+	}
+}
+
+func TestFlattenAndExplodeWithZip(t *testing.T) {
+	jsonString := `
+{
+  "sensor": "a",
+  "timestamps": [1, 2, 3],
+  "values": [10.5, 11.5, 12.5],
+  "quality": ["good", "bad", "good"]
+}
+`
+	var object = jsonnode.NewObject()
+	err := json.Unmarshal([]byte(jsonString), &object)
+	if err != nil {
+		t.Fatal("Could not unmarshal JSON", err)
+	}
+	options := &flattenOptions{explodeDataPaths: []string{"timestamps", "values", "quality"}, zip: true}
+	results, err := flattenAndExplode(object, "", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Incorrect results size! size is %d", len(results))
+	}
+	for i, expected := range [][]string{{"1", "10.5", "good"}, {"2", "11.5", "bad"}, {"3", "12.5", "good"}} {
+		result := results[i]
+		if result.Get("sensor") != jsonnode.String("a") ||
+			result.Get("timestamps") != jsonnode.Number(expected[0]) ||
+			result.Get("values") != jsonnode.Number(expected[1]) ||
+			result.Get("quality") != jsonnode.String(expected[2]) {
+			t.Errorf("Unexpected result %d: %s", i, result.Serialize())
+		}
+	}
+
+	object.Get("values").(*jsonnode.Array).Add(jsonnode.Number("13.5"))
+	_, err = flattenAndExplode(object, "", options)
+	if err == nil {
+		t.Error("Expected an error when zipping arrays of different lengths")
+	}
+	if _, err := newFlattenOptions(querymodel.ParsingOption{ExplodeMode: "Zip"}); err == nil || !strings.Contains(err.Error(), "unknown explode mode") {
+		t.Errorf("Expected an error for an unknown explode mode, but got: %v", err)
+	}
+}
+
+func TestFlattenAndExplodeRowLimit(t *testing.T) {
+	jsonString := `
+{
+  "a": [1, 2, 3, 4, 5],
+  "b": [1, 2, 3, 4, 5],
+  "c": [1, 2, 3, 4, 5]
+}
+`
+	var object = jsonnode.NewObject()
+	err := json.Unmarshal([]byte(jsonString), &object)
+	if err != nil {
+		t.Fatal("Could not unmarshal JSON", err)
+	}
+	results, err := flattenAndExplode(object, "", &flattenOptions{explodeDataPaths: []string{"a", "b", "c"}, maxRows: 125})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 125 {
+		t.Errorf("Incorrect results size! size is %d", len(results))
+	}
+	_, err = flattenAndExplode(object, "", &flattenOptions{explodeDataPaths: []string{"a", "b", "c"}, maxRows: 100})
+	if err == nil {
+		t.Fatal("Expected an error when the row limit is exceeded")
+	}
+	if !strings.Contains(err.Error(), "[a, b, c]") {
+		t.Errorf("Expected the error to name the exploded paths. error: %v", err)
+	}
+}
+
+func TestFlattenAndExplodeKeepJson(t *testing.T) {
+	jsonString := `
+{
+  "name": "a",
+  "location": { "lat": 1.5, "lng": 2.5, "address": { "city": "x" } },
+  "tags": ["one", "two"],
+  "readings": [
+    { "value": 1, "meta": { "source": "s" } },
+    { "value": 2, "meta": { "source": "t" } }
+  ]
+}
+`
+	var object = jsonnode.NewObject()
+	err := json.Unmarshal([]byte(jsonString), &object)
+	if err != nil {
+		t.Fatal("Could not unmarshal JSON", err)
+	}
+	results, err := flattenAndExplode(object, "", &flattenOptions{keepJsonPaths: []string{"tags", "location.address"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := results[0]
+	if _, isArray := result.Get("tags").(*jsonnode.Array); !isArray {
+		t.Errorf("Expected tags to be kept as an array: %s", result.Serialize())
+	}
+	if _, isObject := result.Get("location.address").(*jsonnode.Object); !isObject || result.Get("location.lat") == nil {
+		t.Errorf("Expected only location.address to be kept as an object: %s", result.Serialize())
+	}
+
+	flattenDepth := 0
+	results, err = flattenAndExplode(object, "", &flattenOptions{explodeDataPaths: []string{"readings"}, flattenDepth: &flattenDepth})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Incorrect results size! size is %d", len(results))
+	}
+	for _, result := range results {
+		if _, isObject := result.Get("location").(*jsonnode.Object); !isObject {
+			t.Errorf("Expected location to be kept as an object: %s", result.Serialize())
+		}
+		if _, isObject := result.Get("readings.meta").(*jsonnode.Object); !isObject || result.Get("readings.value") == nil {
+			t.Errorf("Expected the exploded readings to be flattened with meta kept as an object: %s", result.Serialize())
+		}
+	}
+}
+
+func TestParseDataKeepJson(t *testing.T) {
+	frames, err := parseTestData(t, `{"data": [{"id": 1, "location": {"lat": 1.5, "lng": 2.5}}]}`, querymodel.ParsingOption{
+		DataPath:      "data",
+		KeepJsonPaths: []string{"location"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	field, _ := frames[0].FieldByName("location")
+	if field == nil || field.Type() != data.FieldTypeNullableJSON {
+		t.Fatal("Expected location to be a JSON field")
+	}
+	if value, _ := field.ConcreteAt(0); string(value.(json.RawMessage)) != `{"lat":1.5,"lng":2.5}` {
+		t.Errorf("Unexpected location: %s", value)
+	}
+}
+
+func TestFlattenAndExplodeWildcardParents(t *testing.T) {
+	for _, test := range []struct {
+		jsonString   string
+		explodePath  string
+		expectedRows int
+	}{
+		// Only the wildcard parent that leads to readings is exploded, so tags are not crossed with readings
+		{`{"devices": {"battery": {"tags": ["x", "y"], "readings": [{"v": 1}, {"v": 2}]}}}`, "devices.*.readings", 2},
+		{`{"devices": [{"tags": ["x", "y"], "readings": [{"v": 1}, {"v": 2}]}]}`, "devices.readings", 2},
+		{`{"devices": [{"tags": ["x", "y"], "readings": [{"v": 1}, {"v": 2}]}]}`, "devices.*.readings", 1},
+	} {
+		object, err := jsonnode.Decode([]byte(test.jsonString))
+		if err != nil {
+			t.Fatal(err)
+		}
+		options, err := newFlattenOptions(querymodel.ParsingOption{ExplodeArrayPaths: []string{test.explodePath}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		results, err := flattenAndExplode(object.(*jsonnode.Object), "", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != test.expectedRows {
+			t.Errorf("Expected %d rows for %s but got %d", test.expectedRows, test.explodePath, len(results))
+		}
+	}
+}
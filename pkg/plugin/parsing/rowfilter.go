@@ -0,0 +1,51 @@
+package parsing
+
+import (
+	"fmt"
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/util/jsonnode"
+	"reflect"
+)
+
+// the purpose of this file is to drop rows that do not match the row filter of a querymodel.ParsingOption
+
+type rowFilter struct {
+	program *vm.Program
+}
+
+// compileRowFilter compiles the row filter expression, or returns nil if expression is blank. Any error returned is a friendly error.
+func compileRowFilter(expression string) (*rowFilter, error) {
+	if expression == "" {
+		return nil, nil
+	}
+	program, err := expr.Compile(expression, expr.AllowUndefinedVariables())
+	if err != nil {
+		return nil, fmt.Errorf("could not compile row filter error: %w", err)
+	}
+	return &rowFilter{program: program}, nil
+}
+
+// matches determines if the row should be kept. environment may be nil, in which case it is created from flatData.
+// A nil result or an expression that cannot be evaluated for this row does not match, but any other result that is not a boolean causes a friendly error.
+func (filter *rowFilter) matches(flatData *jsonnode.Object, environment map[string]any, keys keyFormat) (bool, error) {
+	if environment == nil {
+		var err error
+		environment, err = newExpressionEnvironment(flatData, keys)
+		if err != nil {
+			return false, err
+		}
+	}
+	result, err := expr.Run(filter.program, environment)
+	if err != nil {
+		// Such as comparing a field that does not exist in this row, which is treated the same as a nil result
+		return false, nil
+	}
+	switch typedResult := result.(type) {
+	case bool:
+		return typedResult, nil
+	case nil:
+		return false, nil
+	}
+	return false, fmt.Errorf("row filter must result in a boolean, but resulted in: %v of type: %v", result, reflect.TypeOf(result))
+}
@@ -0,0 +1,79 @@
+package parsing
+
+import (
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"strings"
+	"testing"
+)
+
+func TestRowFilter(t *testing.T) {
+	jsonString := `
+{
+  "data": [
+    { "host": "a", "status": "ok", "packet": { "voltage": 12, "current": 2.5 } },
+    { "host": "b", "status": "error", "packet": { "voltage": 12.5, "current": 1 } },
+    { "host": "c", "status": "ok", "packet": { "voltage": 11, "current": 0 } },
+    { "host": "d", "packet": { "voltage": 13, "current": 3 } }
+  ]
+}
+`
+	frames, err := parseTestData(t, jsonString, querymodel.ParsingOption{
+		DataPath: "data",
+		ComputedFields: []querymodel.ComputedField{
+			{Name: "power", Expression: "packet.voltage * packet.current"},
+		},
+		RowFilter: `status == "ok" && power > 0`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	field, _ := frames[0].FieldByName("host")
+	if field == nil {
+		t.Fatal("Expected field host to exist")
+	}
+	if value, _ := field.ConcreteAt(0); field.Len() != 1 || value != "a" {
+		t.Errorf("Expected only host a to remain, but there were %d rows", field.Len())
+	}
+}
+
+func TestRowFilterNil(t *testing.T) {
+	frames, err := parseTestData(t, `{"data": [{"value": 1, "enabled": true}, {"value": 2}]}`, querymodel.ParsingOption{
+		DataPath:  "data",
+		RowFilter: "enabled",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	field, _ := frames[0].FieldByName("value")
+	if field == nil || field.Len() != 1 {
+		t.Fatal("Expected the row without enabled to be dropped")
+	}
+}
+
+func TestRowFilterMissingField(t *testing.T) {
+	frames, err := parseTestData(t, `{"data": [{"host": "a", "value": 10}, {"host": "b"}, {"host": "c", "value": 3}]}`, querymodel.ParsingOption{
+		DataPath:  "data",
+		RowFilter: "value > 5",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFieldValues(t, frames[0], map[string][]any{"host": {"a"}})
+}
+
+func TestRowFilterErrors(t *testing.T) {
+	_, err := parseTestData(t, `{"data": [{"value": 1}]}`, querymodel.ParsingOption{
+		DataPath:  "data",
+		RowFilter: "value >",
+	})
+	if err == nil || !strings.Contains(err.Error(), "row filter") {
+		t.Errorf("Expected a compile error, but got: %v", err)
+	}
+	_, err = parseTestData(t, `{"data": [{"value": 1}]}`, querymodel.ParsingOption{
+		DataPath:  "data",
+		RowFilter: "value + 1",
+	})
+	if err == nil || !strings.Contains(err.Error(), "boolean") {
+		t.Errorf("Expected an error for a non-boolean result, but got: %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package parsing
+
+import (
+	"fmt"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/parsing/framemap"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+)
+
+// the purpose of this file is to configure the sorting, deduplication and row limit of each series of a framemap.FrameMap
+
+// setRowOrder configures the sorting, deduplication and row limit of fm, or returns a friendly error if they are misconfigured
+func setRowOrder(fm *framemap.FrameMap, parsingOption querymodel.ParsingOption, detectedKeys []string) error {
+	timeField := primaryTimeField(parsingOption, detectedKeys)
+	switch parsingOption.SortOrder {
+	case querymodel.NO_SORT, "":
+	case querymodel.ASCENDING, querymodel.DESCENDING:
+		sortField := parsingOption.SortFieldPath
+		if sortField == "" {
+			sortField = timeField
+		}
+		if sortField == "" {
+			return fmt.Errorf("sorting requires a sort field or a time field")
+		}
+		fm.SetSorting(sortField, parsingOption.SortOrder == querymodel.DESCENDING)
+	default:
+		return fmt.Errorf("unknown sort order: %s", parsingOption.SortOrder)
+	}
+	switch parsingOption.DuplicatePolicy {
+	case querymodel.KEEP_DUPLICATES, "":
+	case querymodel.DROP_EXACT_DUPLICATES:
+		fm.SetDeduplication(framemap.DROP_EXACT_DUPLICATES, "")
+	case querymodel.DROP_SAME_TIME:
+		if timeField == "" {
+			return fmt.Errorf("dropping rows with the same time requires a time field")
+		}
+		fm.SetDeduplication(framemap.DROP_SAME_KEY, timeField)
+	default:
+		return fmt.Errorf("unknown duplicate policy: %s", parsingOption.DuplicatePolicy)
+	}
+	if parsingOption.RowsPerSeries != nil {
+		if *parsingOption.RowsPerSeries <= 0 {
+			return fmt.Errorf("rows per series must be greater than 0")
+		}
+		switch parsingOption.RowsPerSeriesFrom {
+		case querymodel.FIRST_ROWS, "", querymodel.LAST_ROWS:
+		default:
+			return fmt.Errorf("unknown value: %s for which rows per series are kept", parsingOption.RowsPerSeriesFrom)
+		}
+		fm.SetRowLimit(*parsingOption.RowsPerSeries, parsingOption.RowsPerSeriesFrom == querymodel.LAST_ROWS)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package parsing
+
+import (
+	"fmt"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"strings"
+	"testing"
+)
+
+const rowOrderTestJson = `
+{
+  "data": [
+    { "time": 1704067260000, "value": 2, "priority": 1 },
+    { "time": 1704067200000, "value": 1, "priority": null },
+    { "time": 1704067380000, "value": 4, "priority": 3 },
+    { "time": 1704067260000, "value": 2, "priority": 1 },
+    { "time": 1704067320000, "value": 3, "priority": 2 },
+    { "time": 1704067320000, "value": 5, "priority": 5 }
+  ]
+}
+`
+
+func TestRowOrder(t *testing.T) {
+	limit := 3
+	for _, test := range []struct {
+		parsingOption querymodel.ParsingOption
+		expected      string
+	}{
+		{querymodel.ParsingOption{SortOrder: querymodel.ASCENDING}, "[1 2 2 3 5 4]"},
+		{querymodel.ParsingOption{SortOrder: querymodel.DESCENDING}, "[4 3 5 2 2 1]"},
+		{querymodel.ParsingOption{SortOrder: querymodel.DESCENDING, SortFieldPath: "priority"}, "[5 4 3 2 2 1]"},
+		{querymodel.ParsingOption{SortOrder: querymodel.ASCENDING, SortFieldPath: "priority"}, "[2 2 3 4 5 1]"},
+		{querymodel.ParsingOption{DuplicatePolicy: querymodel.DROP_EXACT_DUPLICATES}, "[2 1 4 3 5]"},
+		{querymodel.ParsingOption{SortOrder: querymodel.ASCENDING, DuplicatePolicy: querymodel.DROP_SAME_TIME}, "[1 2 5 4]"},
+		{querymodel.ParsingOption{SortOrder: querymodel.ASCENDING, RowsPerSeries: &limit}, "[1 2 2]"},
+		{querymodel.ParsingOption{SortOrder: querymodel.ASCENDING, RowsPerSeries: &limit, RowsPerSeriesFrom: querymodel.LAST_ROWS}, "[3 5 4]"},
+	} {
+		parsingOption := test.parsingOption
+		parsingOption.DataPath = "data"
+		parsingOption.TimeFields = []querymodel.TimeField{{TimePath: "time"}}
+		frames, err := parseTestData(t, rowOrderTestJson, parsingOption)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if values := fmt.Sprint(valuesOf(frames[0], "value")); values != test.expected {
+			t.Errorf("Expected %s but got %s for %+v", test.expected, values, test.parsingOption)
+		}
+	}
+}
+
+func TestRowOrderErrors(t *testing.T) {
+	_, err := parseTestData(t, `{"data": [{"value": 1}]}`, querymodel.ParsingOption{DataPath: "data", SortOrder: querymodel.ASCENDING})
+	if err == nil || !strings.Contains(err.Error(), "sorting requires") {
+		t.Errorf("Expected an error when there is no field to sort by, but got: %v", err)
+	}
+}
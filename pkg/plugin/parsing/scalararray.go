@@ -0,0 +1,38 @@
+package parsing
+
+import (
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/util/jsonnode"
+	"strings"
+)
+
+// the purpose of this file is to combine arrays of scalars, such as tags: ["a", "b"], into a single value when flattening
+
+// DEFAULT_SCALAR_ARRAY_DELIMITER is used to join scalar arrays when querymodel.ParsingOption.ScalarArrayDelimiter is not set
+const DEFAULT_SCALAR_ARRAY_DELIMITER = ","
+
+// isScalarArray determines if every element of array is a string, number, boolean or null. An empty array is a scalar array.
+func isScalarArray(array *jsonnode.Array) bool {
+	for _, element := range *array {
+		switch element.(type) {
+		case *jsonnode.Object, *jsonnode.Array:
+			return false
+		}
+	}
+	return true
+}
+
+// joinScalarArray joins the elements of a scalar array using delimiter. Null elements become blank strings.
+func joinScalarArray(array *jsonnode.Array, delimiter string) string {
+	parts := make([]string, len(*array))
+	for i, element := range *array {
+		switch typedElement := element.(type) {
+		case jsonnode.String:
+			parts[i] = typedElement.String()
+		case jsonnode.Null:
+			parts[i] = ""
+		default:
+			parts[i] = string(element.Serialize())
+		}
+	}
+	return strings.Join(parts, delimiter)
+}
@@ -0,0 +1,63 @@
+package parsing
+
+import (
+	"encoding/json"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"testing"
+)
+
+const scalarArrayTestData = `
+{
+  "data": [
+    { "name": "a", "tags": ["x", "y"], "sizes": [1, 2.5, null], "points": [{ "value": 1 }] },
+    { "name": "b", "tags": [], "sizes": [3], "points": [{ "value": 2 }] }
+  ]
+}
+`
+
+func TestJoinScalarArrays(t *testing.T) {
+	delimiter := "|"
+	frames, err := parseTestData(t, scalarArrayTestData, querymodel.ParsingOption{
+		DataPath:             "data",
+		ScalarArrayMode:      querymodel.JOIN_SCALARS,
+		ScalarArrayDelimiter: &delimiter,
+		LabelOptions:         []querymodel.LabelOption{{Name: "tags", Type: querymodel.FIELD, Value: "tags"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 2 {
+		t.Fatalf("Expected 2 frames but got %d", len(frames))
+	}
+	if frames[0].Fields[0].Labels["tags"] != "x|y" {
+		t.Errorf("Unexpected labels: %v", frames[0].Fields[0].Labels)
+	}
+	for i, expected := range []string{"1|2.5|", "3"} {
+		field, _ := frames[i].FieldByName("sizes")
+		if actual, _ := field.ConcreteAt(0); actual != expected {
+			t.Errorf("Expected sizes of frame %d to be %s but was %v", i, expected, actual)
+		}
+	}
+	if field, _ := frames[0].FieldByName("points.0.value"); field == nil {
+		t.Error("Expected arrays of objects to still be flattened")
+	}
+}
+
+func TestScalarArraysAsJson(t *testing.T) {
+	frames, err := parseTestData(t, scalarArrayTestData, querymodel.ParsingOption{
+		DataPath:        "data",
+		ScalarArrayMode: querymodel.SCALARS_AS_JSON,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	field, _ := frames[0].FieldByName("tags")
+	if field == nil {
+		t.Fatal("Expected tags to be a single field")
+	}
+	for i, expected := range []string{`["x","y"]`, `[]`} {
+		if actual, _ := field.ConcreteAt(i); string(actual.(json.RawMessage)) != expected {
+			t.Errorf("Expected tags %d to be %s but was %s", i, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package parsing
+
+import (
+	"fmt"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/parsing/framemap"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+)
+
+// the purpose of this file is to configure the series limit of a querymodel.ParsingOption on a framemap.FrameMap
+
+// setSeriesLimit configures the series limit of fm, or returns a friendly error if the series limit is misconfigured
+func setSeriesLimit(fm *framemap.FrameMap, parsingOption querymodel.ParsingOption, detectedKeys []string) error {
+	if parsingOption.SeriesLimit == nil {
+		return nil
+	}
+	if *parsingOption.SeriesLimit <= 0 {
+		return fmt.Errorf("the series limit must be greater than 0")
+	}
+	switch parsingOption.SeriesLimitRank {
+	case querymodel.TOP_SERIES, querymodel.BOTTOM_SERIES, "":
+	default:
+		return fmt.Errorf("unknown series limit rank: %s", parsingOption.SeriesLimitRank)
+	}
+	var aggregateType framemap.AggregateType
+	switch parsingOption.SeriesLimitAggregate {
+	case querymodel.AGGREGATE_LAST, "":
+		aggregateType = framemap.AGGREGATE_LAST
+	case querymodel.AGGREGATE_MAX:
+		aggregateType = framemap.AGGREGATE_MAX
+	case querymodel.AGGREGATE_MEAN:
+		aggregateType = framemap.AGGREGATE_MEAN
+	case querymodel.AGGREGATE_SUM:
+		aggregateType = framemap.AGGREGATE_SUM
+	default:
+		return fmt.Errorf("unknown series limit aggregate: %s", parsingOption.SeriesLimitAggregate)
+	}
+	fm.SetSeriesLimit(&framemap.SeriesLimit{
+		Limit:          *parsingOption.SeriesLimit,
+		Bottom:         parsingOption.SeriesLimitRank == querymodel.BOTTOM_SERIES,
+		Field:          parsingOption.SeriesLimitFieldPath,
+		Aggregate:      aggregateType,
+		MergeRemaining: parsingOption.MergeOtherSeries,
+		TimeField:      primaryTimeField(parsingOption, detectedKeys),
+	})
+	return nil
+}
@@ -0,0 +1,109 @@
+package parsing
+
+import (
+	"fmt"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"strings"
+	"testing"
+)
+
+const seriesLimitTestJson = `
+{
+  "data": [
+    { "time": 1704067200000, "device": "a", "power": 10 },
+    { "time": 1704067200000, "device": "b", "power": 50 },
+    { "time": 1704067200000, "device": "c", "power": 30 },
+    { "time": 1704067200000, "device": "d", "power": 1 },
+    { "time": 1704067260000, "device": "a", "power": 90 },
+    { "time": 1704067260000, "device": "b", "power": 5 },
+    { "time": 1704067260000, "device": "c", "power": 20 },
+    { "time": 1704067260000, "device": "d", "power": null }
+  ]
+}
+`
+
+func TestSeriesLimit(t *testing.T) {
+	limit := 2
+	for _, test := range []struct {
+		parsingOption querymodel.ParsingOption
+		expected      string
+	}{
+		{querymodel.ParsingOption{}, "a,c"},
+		{querymodel.ParsingOption{SeriesLimitAggregate: querymodel.AGGREGATE_MAX}, "a,b"},
+		{querymodel.ParsingOption{SeriesLimitAggregate: querymodel.AGGREGATE_MEAN, SeriesLimitRank: querymodel.BOTTOM_SERIES}, "c,d"},
+		{querymodel.ParsingOption{SeriesLimitAggregate: querymodel.AGGREGATE_SUM}, "a,b"},
+		{querymodel.ParsingOption{MergeOtherSeries: true}, "a,c,other"},
+	} {
+		parsingOption := test.parsingOption
+		parsingOption.DataPath = "data"
+		parsingOption.TimeFields = []querymodel.TimeField{{TimePath: "time"}}
+		parsingOption.LabelOptions = []querymodel.LabelOption{{Name: "device", Type: querymodel.FIELD, Value: "device"}}
+		parsingOption.SeriesLimit = &limit
+		frames, err := parseTestData(t, seriesLimitTestJson, parsingOption)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var devices []string
+		for _, frame := range frames {
+			devices = append(devices, frame.Fields[0].Labels["device"])
+		}
+		if strings.Join(devices, ",") != test.expected {
+			t.Errorf("Expected %s but got %s for %+v", test.expected, devices, test.parsingOption)
+		}
+	}
+}
+
+func TestSeriesLimitMergeOther(t *testing.T) {
+	limit := 2
+	frames, err := parseTestData(t, seriesLimitTestJson, querymodel.ParsingOption{
+		DataPath:         "data",
+		TimeFields:       []querymodel.TimeField{{TimePath: "time"}},
+		LabelOptions:     []querymodel.LabelOption{{Name: "device", Type: querymodel.FIELD, Value: "device"}},
+		SeriesLimit:      &limit,
+		MergeOtherSeries: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := frames[2]
+	// The power of b and d are summed at each time
+	if values := fmt.Sprint(valuesOf(other, "power")); values != "[51 5]" {
+		t.Errorf("Unexpected values of the other series: %s", values)
+	}
+}
+
+func TestSeriesLimitMergeOtherWithOtherLabel(t *testing.T) {
+	limit := 2
+	// The series of device a is kept, and has the same labels as the series that the remaining series are merged into would have
+	frames, err := parseTestData(t, strings.ReplaceAll(seriesLimitTestJson, `"device": "a"`, `"device": "other"`), querymodel.ParsingOption{
+		DataPath:         "data",
+		TimeFields:       []querymodel.TimeField{{TimePath: "time"}},
+		LabelOptions:     []querymodel.LabelOption{{Name: "device", Type: querymodel.FIELD, Value: "device"}},
+		SeriesLimit:      &limit,
+		MergeOtherSeries: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var devices []string
+	for _, frame := range frames {
+		devices = append(devices, frame.Fields[0].Labels["device"])
+	}
+	if strings.Join(devices, ",") != "other,c,other 2" {
+		t.Fatalf("Expected the remaining series to be merged into a distinct series, but got %s", devices)
+	}
+	if values := fmt.Sprint(valuesOf(frames[0], "power")); values != "[10 90]" {
+		t.Errorf("Expected the kept series to keep only its own values, but got %s", values)
+	}
+	if values := fmt.Sprint(valuesOf(frames[2], "power")); values != "[51 5]" {
+		t.Errorf("Unexpected values of the other series: %s", values)
+	}
+}
+
+func TestSeriesLimitErrors(t *testing.T) {
+	limit := 0
+	_, err := parseTestData(t, seriesLimitTestJson, querymodel.ParsingOption{DataPath: "data", SeriesLimit: &limit})
+	if err == nil || !strings.Contains(err.Error(), "greater than 0") {
+		t.Errorf("Expected an error for a series limit of 0, but got: %v", err)
+	}
+}
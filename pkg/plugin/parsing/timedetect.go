@@ -0,0 +1,169 @@
+package parsing
+
+import (
+	"fmt"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/util/jsonnode"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// the purpose of this file is to automatically detect time fields when querymodel.ParsingOption.AutoDetectTimeFields is enabled
+
+// timeLikeWords are the words of a name that make it look like the name of a timestamp
+var timeLikeWords = map[string]bool{
+	"time":      true,
+	"timestamp": true,
+	"datetime":  true,
+	"date":      true,
+	"epoch":     true,
+	"ts":        true,
+}
+
+// nameWords splits a camelCase, snake_case or kebab-case name into its lowercase words
+func nameWords(name string) []string {
+	var words []string
+	var word []rune
+	var previous rune
+	for _, c := range name {
+		startsWord := unicode.IsUpper(c) && (unicode.IsLower(previous) || unicode.IsDigit(previous))
+		if c == '_' || c == '-' || c == ' ' || startsWord {
+			if len(word) > 0 {
+				words = append(words, strings.ToLower(string(word)))
+			}
+			word = nil
+		}
+		if c != '_' && c != '-' && c != ' ' {
+			word = append(word, c)
+		}
+		previous = c
+	}
+	if len(word) > 0 {
+		words = append(words, strings.ToLower(string(word)))
+	}
+	return words
+}
+
+// isTimeLikeName determines if the last part of a flattened key looks like the name of a timestamp, such as dateMillis, timestamp or createdAt.
+// Whole words are matched, so names such as uptime, candidate and updated do not look like timestamps.
+func isTimeLikeName(key string, separator string) bool {
+	name := key
+	if index := strings.LastIndex(key, separator); index >= 0 {
+		name = key[index+len(separator):]
+	}
+	words := nameWords(name)
+	for _, word := range words {
+		if timeLikeWords[word] {
+			return true
+		}
+	}
+	return len(words) > 1 && words[len(words)-1] == "at"
+}
+
+// epochUnitsByDigits maps the number of digits of a present day epoch timestamp to its unit
+var epochUnitsByDigits = map[int]time.Duration{
+	10: time.Second,
+	13: time.Millisecond,
+	16: time.Microsecond,
+	19: time.Nanosecond,
+}
+
+// detectTimeParser returns a parser for the given values if they all look like timestamps, or nil otherwise.
+//
+// Strings are detected when every one of them is an RFC 3339 timestamp.
+// Numbers are detected when every one of them is a 13-digit epoch millisecond timestamp inside the time range.
+// When the name looks like a timestamp, numbers in epoch seconds, microseconds and nanoseconds are detected as well, and the time range is not checked.
+func detectTimeParser(key string, values []jsonnode.Node, timeRange backend.TimeRange, separator string) *timeParser {
+	if len(values) == 0 {
+		return nil
+	}
+	timeLikeName := isTimeLikeName(key, separator)
+	if _, isString := values[0].(jsonnode.String); isString {
+		for _, value := range values {
+			typedValue, ok := value.(jsonnode.String)
+			if !ok {
+				return nil
+			}
+			if _, err := time.Parse(time.RFC3339, typedValue.String()); err != nil {
+				return nil
+			}
+		}
+		return &timeParser{layout: time.RFC3339, unit: time.Millisecond, location: time.UTC}
+	}
+	var unit time.Duration
+	for _, value := range values {
+		typedValue, ok := value.(jsonnode.Number)
+		if !ok {
+			return nil
+		}
+		epoch, err := strconv.ParseInt(typedValue.String(), 10, 64)
+		if err != nil || epoch <= 0 {
+			return nil
+		}
+		valueUnit, isEpoch := epochUnitsByDigits[len(typedValue.String())]
+		if !isEpoch || (unit != 0 && valueUnit != unit) || (!timeLikeName && valueUnit != time.Millisecond) {
+			return nil
+		}
+		unit = valueUnit
+		if !timeLikeName {
+			t := time.UnixMilli(epoch)
+			if timeRange.From.IsZero() || timeRange.To.IsZero() || t.Before(timeRange.From) || t.After(timeRange.To) {
+				return nil
+			}
+		}
+	}
+	return &timeParser{layout: time.RFC3339, unit: unit, location: time.UTC}
+}
+
+// detectTimeFields inspects every row of flatDataArray and adds a parser to timeParsers for each field that looks like a timestamp in every row.
+// Fields that already have a parser, that are configured by a type hint, number field or value map of parsingOption, or that are excluded from the data frame are not considered.
+// The keys of the detected fields are returned. separator is the separator between the parts of flattened keys.
+func detectTimeFields(flatDataArray []*jsonnode.Object, parsingOption *querymodel.ParsingOption, timeRange backend.TimeRange, timeParsers map[string]*timeParser, filter *fieldFilter, separator string) []string {
+	var keys []string
+	valuesByKey := map[string][]jsonnode.Node{}
+	for _, flatData := range flatDataArray {
+		for _, key := range filterKeysForDataFrame(flatData.Keys(), filter) {
+			if _, isTimeField := timeParsers[key]; isTimeField {
+				continue
+			}
+			if parsingOption.GetFieldTypeHint(key) != nil || parsingOption.GetNumberField(key) != nil || parsingOption.GetValueMap(key) != nil {
+				continue
+			}
+			values, seen := valuesByKey[key]
+			if !seen {
+				keys = append(keys, key)
+			}
+			value := flatData.Get(key)
+			if _, isNull := value.(jsonnode.Null); !isNull {
+				values = append(values, value)
+			}
+			valuesByKey[key] = values
+		}
+	}
+	var detectedKeys []string
+	for _, key := range keys {
+		parser := detectTimeParser(key, valuesByKey[key], timeRange, separator)
+		if parser != nil {
+			timeParsers[key] = parser
+			detectedKeys = append(detectedKeys, key)
+		}
+	}
+	return detectedKeys
+}
+
+func timeFieldDetectionNotice(detectedKeys []string) data.Notice {
+	if len(detectedKeys) == 0 {
+		return data.Notice{
+			Severity: data.NoticeSeverityInfo,
+			Text:     "Time field detection is enabled, but no fields look like timestamps. Consider configuring a time field manually.",
+		}
+	}
+	return data.Notice{
+		Severity: data.NoticeSeverityInfo,
+		Text:     fmt.Sprintf("Automatically detected time fields: %s", strings.Join(detectedKeys, ", ")),
+	}
+}
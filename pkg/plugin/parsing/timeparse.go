@@ -0,0 +1,142 @@
+package parsing
+
+import (
+	"fmt"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/util/jsonnode"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the purpose of this file is to parse time fields given the format options of a querymodel.TimeField
+
+// namedTimeFormats maps the names of well known formats to their Go layout. Names are matched case-insensitively.
+var namedTimeFormats = map[string]string{
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+	"rfc1123":     time.RFC1123,
+	"rfc1123z":    time.RFC1123Z,
+	"rfc822":      time.RFC822,
+	"rfc822z":     time.RFC822Z,
+	"rfc850":      time.RFC850,
+	"datetime":    time.DateTime,
+	"dateonly":    time.DateOnly,
+}
+
+// EPOCH_TIME_FORMAT is a special time format that parses strings containing a number, such as "1704333209773", using the time unit
+const EPOCH_TIME_FORMAT = "epoch"
+
+type timeParser struct {
+	// layout is the Go layout used to parse strings. Blank when strings should be parsed as epoch numbers
+	layout   string
+	unit     time.Duration
+	location *time.Location
+}
+
+// newTimeParser creates a timeParser for the given time field or returns a friendly error if the time field is misconfigured
+func newTimeParser(timeField querymodel.TimeField) (*timeParser, error) {
+	parser := &timeParser{
+		layout:   time.RFC3339,
+		unit:     time.Millisecond,
+		location: time.UTC,
+	}
+	if timeField.TimeFormat != "" {
+		if strings.EqualFold(timeField.TimeFormat, EPOCH_TIME_FORMAT) {
+			parser.layout = ""
+		} else if layout, isNamed := namedTimeFormats[strings.ToLower(timeField.TimeFormat)]; isNamed {
+			parser.layout = layout
+		} else {
+			parser.layout = timeField.TimeFormat
+		}
+	}
+	switch timeField.TimeUnit {
+	case querymodel.SECONDS:
+		parser.unit = time.Second
+	case querymodel.MILLISECONDS, "":
+		parser.unit = time.Millisecond
+	case querymodel.MICROSECONDS:
+		parser.unit = time.Microsecond
+	case querymodel.NANOSECONDS:
+		parser.unit = time.Nanosecond
+	default:
+		return nil, fmt.Errorf("unknown time unit: %s for time path: %s", timeField.TimeUnit, timeField.TimePath)
+	}
+	if timeField.TimeZone != "" {
+		location, err := time.LoadLocation(timeField.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("unknown time zone: %s for time path: %s", timeField.TimeZone, timeField.TimePath)
+		}
+		parser.location = location
+	}
+	return parser, nil
+}
+
+// newTimeParsers creates a map of time paths to their parser. Time fields with a blank time path are ignored.
+func newTimeParsers(timeFields []querymodel.TimeField) (map[string]*timeParser, error) {
+	r := map[string]*timeParser{}
+	for _, timeField := range timeFields {
+		if timeField.TimePath == "" {
+			continue
+		}
+		parser, err := newTimeParser(timeField)
+		if err != nil {
+			return nil, err
+		}
+		r[timeField.TimePath] = parser
+	}
+	return r, nil
+}
+
+// parse parses the given value into a time, or nil if the value is null. Any error returned is a friendly error
+func (parser *timeParser) parse(value jsonnode.Node) (*time.Time, error) {
+	switch typedValue := value.(type) {
+	case jsonnode.String:
+		if parser.layout == "" {
+			return parser.parseEpoch(jsonnode.Number(strings.TrimSpace(typedValue.String())))
+		}
+		// Note that the location is only used when the layout does not contain time zone information
+		parsedTime, err := time.ParseInLocation(parser.layout, typedValue.String(), parser.location)
+		if err != nil {
+			return nil, fmt.Errorf("time could not be parsed! Time: %s", typedValue)
+		}
+		return &parsedTime, nil
+	case jsonnode.Number:
+		return parser.parseEpoch(typedValue)
+	case jsonnode.Null:
+		return nil, nil
+	}
+	// This case should never happen because we never expect other types to pop up here
+	return nil, fmt.Errorf("unsupported time type! Time: %s type: %v", value, reflect.TypeOf(value))
+}
+
+func (parser *timeParser) parseEpoch(number jsonnode.Number) (*time.Time, error) {
+	var t time.Time
+	if epoch, err := strconv.ParseInt(number.String(), 10, 64); err == nil {
+		switch parser.unit {
+		case time.Second:
+			t = time.Unix(epoch, 0)
+		case time.Millisecond:
+			t = time.UnixMilli(epoch)
+		case time.Microsecond:
+			t = time.UnixMicro(epoch)
+		default:
+			t = time.Unix(0, epoch)
+		}
+		return &t, nil
+	}
+	// Fractional values such as epoch seconds with millisecond precision
+	epoch, err := strconv.ParseFloat(number.String(), 64)
+	if err != nil || math.IsNaN(epoch) || math.IsInf(epoch, 0) {
+		return nil, fmt.Errorf("time could not be parsed as a number! Time: %s", number)
+	}
+	seconds, fraction := math.Modf(epoch * parser.unit.Seconds())
+	// float64(math.MaxInt64) is 2^63, which does not fit in an int64, so the upper bound is exclusive
+	if seconds >= math.MaxInt64 || seconds < math.MinInt64 {
+		return nil, fmt.Errorf("time is out of range! Time: %s", number)
+	}
+	t = time.Unix(int64(seconds), int64(fraction*float64(time.Second)))
+	return &t, nil
+}
@@ -0,0 +1,185 @@
+package parsing
+
+import (
+	"fmt"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/util/jsonnode"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeParser(t *testing.T) {
+	expected := time.Date(2024, 1, 4, 2, 3, 4, 500_000_000, time.UTC)
+	for i, testCase := range []struct {
+		timeField querymodel.TimeField
+		value     jsonnode.Node
+	}{
+		{querymodel.TimeField{}, jsonnode.Number("1704333784500")},
+		{querymodel.TimeField{}, jsonnode.String("2024-01-04T02:03:04.5Z")},
+		{querymodel.TimeField{TimeUnit: querymodel.SECONDS}, jsonnode.Number("1704333784.5")},
+		{querymodel.TimeField{TimeUnit: querymodel.MICROSECONDS}, jsonnode.Number("1704333784500000")},
+		{querymodel.TimeField{TimeUnit: querymodel.NANOSECONDS}, jsonnode.Number("1704333784500000000")},
+		{querymodel.TimeField{TimeFormat: "RFC3339Nano"}, jsonnode.String("2024-01-03T20:03:04.500000000-06:00")},
+		{querymodel.TimeField{TimeFormat: "epoch", TimeUnit: querymodel.SECONDS}, jsonnode.String("1704333784.5")},
+		{querymodel.TimeField{TimeFormat: "2006-01-02 15:04:05.0"}, jsonnode.String("2024-01-04 02:03:04.5")},
+		{querymodel.TimeField{TimeFormat: "2006-01-02 15:04:05.0", TimeZone: "America/Chicago"}, jsonnode.String("2024-01-03 20:03:04.5")},
+	} {
+		parser, err := newTimeParser(testCase.timeField)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsedTime, err := parser.parse(testCase.value)
+		if err != nil {
+			t.Errorf("Test case %d got error: %v", i, err)
+			continue
+		}
+		if !parsedTime.Equal(expected) {
+			t.Errorf("Test case %d got unexpected time: %v", i, parsedTime)
+		}
+	}
+}
+
+func TestTimeParserErrors(t *testing.T) {
+	if _, err := newTimeParser(querymodel.TimeField{TimeUnit: "minutes"}); err == nil {
+		t.Error("Expected an error for an unknown time unit")
+	}
+	if _, err := newTimeParser(querymodel.TimeField{TimeZone: "Not/AZone"}); err == nil {
+		t.Error("Expected an error for an unknown time zone")
+	}
+	parser, err := newTimeParser(querymodel.TimeField{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.parse(jsonnode.String("2024-01-04 02:03:04")); err == nil {
+		t.Error("Expected an error for a time that does not match the format")
+	}
+	if parsedTime, err := parser.parse(jsonnode.NULL); err != nil || parsedTime != nil {
+		t.Error("Expected null to result in a nil time")
+	}
+	secondsParser, err := newTimeParser(querymodel.TimeField{TimeUnit: querymodel.SECONDS})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := secondsParser.parse(jsonnode.Number("9223372036854775808.5")); err == nil {
+		t.Error("Expected an error for a time of 2^63 seconds, which does not fit in an int64")
+	}
+}
+
+func TestDetectTimeFields(t *testing.T) {
+	object, err := jsonnode.Decode([]byte(`
+{
+  "data": [
+    { "dateMillis": 1704333784500, "createdAt": 1704333784, "updated": "2024-01-04T02:03:04Z", "id": 1704333784500, "value": 12.5 },
+    { "dateMillis": 1704333785500, "createdAt": 1704333785, "updated": "2024-01-04T02:03:05Z", "id": 1704333785500, "value": 12.6 }
+  ]
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames, _, err := ParseData(object.(*jsonnode.Object), querymodel.ParsingOption{
+		DataPath:             "data",
+		AutoDetectTimeFields: true,
+	}, backend.DataQuery{
+		TimeRange: backend.TimeRange{
+			From: time.UnixMilli(1704333784000),
+			To:   time.UnixMilli(1704333785000),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := frames[0]
+	for _, fieldName := range []string{"dateMillis", "createdAt", "updated"} {
+		field, _ := frame.FieldByName(fieldName)
+		if field == nil || field.Type() != data.FieldTypeNullableTime {
+			t.Errorf("Expected %s to be detected as a time field", fieldName)
+		}
+	}
+	// id does not have a time-like name and its second value falls outside the time range
+	for _, fieldName := range []string{"id", "value"} {
+		field, _ := frame.FieldByName(fieldName)
+		if field == nil || field.Type() == data.FieldTypeNullableTime {
+			t.Errorf("Expected %s to not be detected as a time field", fieldName)
+		}
+	}
+	if frame.Meta == nil || len(frame.Meta.Notices) != 1 || !strings.Contains(frame.Meta.Notices[0].Text, "dateMillis, createdAt, updated") {
+		t.Errorf("Expected a notice naming the detected time fields. meta: %+v", frame.Meta)
+	}
+}
+
+func TestIsTimeLikeName(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"dateMillis":     true,
+		"createdAt":      true,
+		"created_at":     true,
+		"timestamp":      true,
+		"TIMESTAMP":      true,
+		"startTime":      true,
+		"epochSeconds":   true,
+		"ts":             true,
+		"packet.time_ms": true,
+		"uptime":         false,
+		"candidate":      false,
+		"updated":        false,
+		"at":             false,
+		"value":          false,
+	} {
+		if isTimeLikeName(name, ".") != expected {
+			t.Errorf("Expected isTimeLikeName to return %v for %s", expected, name)
+		}
+	}
+	// a separator longer than one character that is not present in the key must not cut off the start of the name
+	if !isTimeLikeName("timestamp", "__") {
+		t.Error("Expected isTimeLikeName to return true for timestamp with the __ separator")
+	}
+	if !isTimeLikeName("packet__timestamp", "__") {
+		t.Error("Expected isTimeLikeName to return true for packet__timestamp with the __ separator")
+	}
+}
+
+func TestDetectTimeFieldsChecksEveryRow(t *testing.T) {
+	var elements []string
+	for i := 0; i < 120; i++ {
+		elements = append(elements, fmt.Sprintf(`{"updatedAt": "2024-01-04T02:03:%02dZ"}`, i%60))
+	}
+	elements = append(elements, `{"updatedAt": "n/a"}`)
+	object, err := jsonnode.Decode([]byte(`{"data": [` + strings.Join(elements, ",") + `]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames, _, err := ParseData(object.(*jsonnode.Object), querymodel.ParsingOption{
+		DataPath:             "data",
+		AutoDetectTimeFields: true,
+	}, backend.DataQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	field, _ := frames[0].FieldByName("updatedAt")
+	if field == nil || field.Type() != data.FieldTypeNullableString {
+		t.Error("Expected updatedAt to not be detected as a time field because one of its values is not a time")
+	}
+}
+
+func TestDetectTimeFieldsSkipsConfiguredFields(t *testing.T) {
+	frames, err := parseTestData(t, `{"data": [{"ts": "2024-01-04T02:03:04Z", "createdAt": 1704333784, "updatedAt": "2024-01-04T02:03:05Z"}]}`, querymodel.ParsingOption{
+		DataPath:             "data",
+		AutoDetectTimeFields: true,
+		FieldTypes:           []querymodel.FieldTypeHint{{FieldPath: "ts", Type: querymodel.STRING}},
+		NumberFields:         []querymodel.NumberField{{FieldPath: "createdAt", NumberType: querymodel.INT64}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := frames[0]
+	assertFieldValues(t, frame, map[string][]any{
+		"ts":        {"2024-01-04T02:03:04Z"},
+		"createdAt": {int64(1704333784)},
+	})
+	if frame.Meta == nil || len(frame.Meta.Notices) != 1 || frame.Meta.Notices[0].Text != "Automatically detected time fields: updatedAt" {
+		t.Errorf("Expected only updatedAt to be detected. meta: %+v", frame.Meta)
+	}
+}
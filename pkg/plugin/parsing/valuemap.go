@@ -0,0 +1,109 @@
+package parsing
+
+import (
+	"fmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/util/jsonnode"
+	"strconv"
+)
+
+// the purpose of this file is to map the values of fields using the value maps of a querymodel.ParsingOption
+
+// validateValueMaps returns a friendly error if any of the value maps are misconfigured
+func validateValueMaps(valueMaps []querymodel.ValueMap) error {
+	for _, valueMap := range valueMaps {
+		seen := map[string]bool{}
+		// The values mapped to each mapped value, which must be unique when emitting Grafana value mappings
+		valuesByMappedValue := map[string]string{}
+		for _, mapping := range valueMap.Mappings {
+			if (mapping.Number == nil) == (mapping.Text == nil) {
+				return fmt.Errorf("the mapping of value: %s for field: %s must map to either a number or a text", mapping.Value, valueMap.FieldPath)
+			}
+			key := mappingKey(mapping.Value)
+			if seen[key] {
+				return fmt.Errorf("value: %s is mapped multiple times for field: %s", mapping.Value, valueMap.FieldPath)
+			}
+			seen[key] = true
+			if valueMap.EmitFieldMappings {
+				mappedValue := mappedValueOf(mapping)
+				if otherValue, exists := valuesByMappedValue[mappedValue]; exists {
+					return fmt.Errorf("values: %s and %s are both mapped to: %s for field: %s, so they cannot be told apart by the emitted field mappings", otherValue, mapping.Value, mappedValue, valueMap.FieldPath)
+				}
+				valuesByMappedValue[mappedValue] = mapping.Value
+			}
+		}
+	}
+	return nil
+}
+
+// mappingKey normalizes value if it is a number, so that a number such as 1.0 matches the mapping of 1
+func mappingKey(value string) string {
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	return value
+}
+
+// mappedValueOf returns the number or text that mapping maps to, as a string
+func mappedValueOf(mapping querymodel.ValueMapping) string {
+	if mapping.Number != nil {
+		return strconv.FormatFloat(*mapping.Number, 'f', -1, 64)
+	}
+	if mapping.Text != nil {
+		return *mapping.Text
+	}
+	return ""
+}
+
+// mapValue returns the node that value is mapped to. Nulls are never mapped, and numbers are matched by their value rather than how they are written.
+// Values without a mapping are returned unchanged, unless valueMap.NullUnmappedValues is true.
+func mapValue(valueMap *querymodel.ValueMap, value jsonnode.Node) jsonnode.Node {
+	var valueKey string
+	_, isNumber := value.(jsonnode.Number)
+	switch typedValue := value.(type) {
+	case jsonnode.String:
+		valueKey = typedValue.String()
+	case jsonnode.Boolean:
+		valueKey = string(value.Serialize())
+	case jsonnode.Number:
+		valueKey = mappingKey(typedValue.String())
+	case jsonnode.Null:
+		return value
+	}
+	for _, mapping := range valueMap.Mappings {
+		mappingValue := mapping.Value
+		if isNumber {
+			mappingValue = mappingKey(mappingValue)
+		}
+		if mappingValue != valueKey {
+			continue
+		}
+		if mapping.Number != nil {
+			return jsonnode.Number(strconv.FormatFloat(*mapping.Number, 'f', -1, 64))
+		}
+		return jsonnode.String(*mapping.Text)
+	}
+	if valueMap.NullUnmappedValues {
+		return jsonnode.NULL
+	}
+	return value
+}
+
+// toGrafanaValueMappings creates the Grafana value mappings that display each mapped value as its original value
+func toGrafanaValueMappings(valueMap querymodel.ValueMap) data.ValueMappings {
+	mapper := data.ValueMapper{}
+	for index, mapping := range valueMap.Mappings {
+		mappedValue := mappedValueOf(mapping)
+		text := mapping.DisplayText
+		if text == "" {
+			text = mapping.Value
+		}
+		mapper[mappedValue] = data.ValueMappingResult{
+			Text:  text,
+			Color: mapping.Color,
+			Index: index,
+		}
+	}
+	return data.ValueMappings{mapper}
+}
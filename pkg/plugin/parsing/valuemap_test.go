@@ -0,0 +1,111 @@
+package parsing
+
+import (
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/wildmountainfarms/wild-graphql-datasource/pkg/plugin/querymodel"
+	"strings"
+	"testing"
+)
+
+func TestValueMaps(t *testing.T) {
+	charging, float, fault := 1.0, 2.0, 3.0
+	on, off := "on", "off"
+	jsonString := `
+{
+  "data": [
+    { "state": "CHARGING", "enabled": true, "mode": "A" },
+    { "state": "FLOAT", "enabled": false, "mode": "B" },
+    { "state": "UNKNOWN", "enabled": null, "mode": "C" },
+    { "state": "FAULT", "enabled": true, "mode": "A" }
+  ]
+}
+`
+	frames, err := parseTestData(t, jsonString, querymodel.ParsingOption{
+		DataPath: "data",
+		ValueMaps: []querymodel.ValueMap{
+			{
+				FieldPath: "state",
+				Mappings: []querymodel.ValueMapping{
+					{Value: "CHARGING", Number: &charging, DisplayText: "Charging", Color: "green"},
+					{Value: "FLOAT", Number: &float},
+					{Value: "FAULT", Number: &fault, Color: "red"},
+				},
+				NullUnmappedValues: true,
+				EmitFieldMappings:  true,
+			},
+			{
+				FieldPath: "enabled",
+				Mappings: []querymodel.ValueMapping{
+					{Value: "true", Text: &on},
+					{Value: "false", Text: &off},
+				},
+			},
+		},
+		FieldConfigs: []querymodel.FieldConfig{{FieldPath: "state", Description: "The charger state"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := frames[0]
+	assertFieldValues(t, frame, map[string][]any{
+		"state":   {1.0, 2.0, nil, 3.0},
+		"enabled": {"on", "off", nil, "on"},
+		"mode":    {"A", "B", "C", "A"},
+	})
+	state, _ := frame.FieldByName("state")
+	if state.Config == nil || state.Config.Description != "The charger state" || len(state.Config.Mappings) != 1 {
+		t.Fatalf("Expected the value mappings to be merged with the field config: %+v", state.Config)
+	}
+	mapper := state.Config.Mappings[0].(data.ValueMapper)
+	if mapper["1"].Text != "Charging" || mapper["1"].Color != "green" || mapper["2"].Text != "FLOAT" || mapper["3"].Index != 2 {
+		t.Errorf("Unexpected value mappings: %+v", mapper)
+	}
+	enabled, _ := frame.FieldByName("enabled")
+	if enabled.Config != nil && len(enabled.Config.Mappings) != 0 {
+		t.Errorf("Expected no value mappings when they are not emitted: %+v", enabled.Config)
+	}
+}
+
+func TestValueMapNumbers(t *testing.T) {
+	on, off := "on", "off"
+	frames, err := parseTestData(t, `{"data": [{"code": 1}, {"code": 1.0}, {"code": 0}, {"code": "1.0"}]}`, querymodel.ParsingOption{
+		DataPath: "data",
+		ValueMaps: []querymodel.ValueMap{
+			{
+				FieldPath: "code",
+				Mappings: []querymodel.ValueMapping{
+					{Value: "1", Text: &on},
+					{Value: "0", Text: &off},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The string "1.0" is not a number, so it must match exactly
+	assertFieldValues(t, frames[0], map[string][]any{"code": {"on", "on", "off", "1.0"}})
+}
+
+func TestValueMapErrors(t *testing.T) {
+	_, err := parseTestData(t, `{"data": [{"state": "OK"}]}`, querymodel.ParsingOption{
+		DataPath:  "data",
+		ValueMaps: []querymodel.ValueMap{{FieldPath: "state", Mappings: []querymodel.ValueMapping{{Value: "OK"}}}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "either a number or a text") {
+		t.Errorf("Expected an error for a mapping without a result, but got: %v", err)
+	}
+
+	fault, errorValue := 3.0, 3.0
+	_, err = parseTestData(t, `{"data": [{"state": "OK"}]}`, querymodel.ParsingOption{
+		DataPath: "data",
+		ValueMaps: []querymodel.ValueMap{{
+			FieldPath:         "state",
+			Mappings:          []querymodel.ValueMapping{{Value: "FAULT", Number: &fault}, {Value: "ERROR", Number: &errorValue}},
+			EmitFieldMappings: true,
+		}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "FAULT and ERROR") {
+		t.Errorf("Expected an error for values mapped to the same number, but got: %v", err)
+	}
+}
@@ -19,15 +19,327 @@ type ParsingOption struct {
 	// The path from the root to the array. This is dot-delimited
 	DataPath          string   `json:"dataPath"`
 	ExplodeArrayPaths []string `json:"explodeArrayPaths"`
+	// How sibling arrays that are exploded are combined. A blank value is the same as CROSS
+	ExplodeMode ExplodeMode `json:"explodeMode"`
+	// The maximum number of rows this parsing option may produce, or nil to use the default.
+	//   This protects the plugin from running out of memory when exploding multiple large arrays.
+	MaxRows *int `json:"maxRows"`
+	// Whether the index of each exploded array element is exposed as a field or label. A blank value is the same as NO_INDEX
+	ExplodeIndexMode ExplodeIndexMode `json:"explodeIndexMode"`
+	// When true, the indexes of exploded arrays that contain other exploded arrays are also exposed
+	ExplodeParentIndexes bool `json:"explodeParentIndexes"`
+	// The number of levels of nested objects and arrays that are flattened, or nil to flatten everything.
+	//   Nested objects and arrays deeper than this are kept as a single JSON field. Exploded arrays are always flattened.
+	FlattenDepth *int `json:"flattenDepth"`
+	// Paths to nested objects and arrays that are kept as a single JSON field rather than being flattened.
+	//   These paths may contain the same wildcards as ExplodeArrayPaths.
+	KeepJsonPaths []string `json:"keepJsonPaths"`
+	// Whether arrays of scalars, such as tags: ["a", "b"], are flattened or combined into a single field. A blank value is the same as FLATTEN_SCALARS
+	ScalarArrayMode ScalarArrayMode `json:"scalarArrayMode"`
+	// The delimiter used when ScalarArrayMode is JOIN_SCALARS, or nil to use ","
+	ScalarArrayDelimiter *string `json:"scalarArrayDelimiter"`
+	// The separator between the parts of flattened keys, such as "values.0.name". A blank value is the same as ".".
+	//   Every path that refers to a flattened key, such as time paths, label field values and explode paths, must use this separator.
+	KeySeparator string `json:"keySeparator"`
+	// How array indexes appear in flattened keys. A blank value is the same as SEPARATOR_INDEX
+	ArrayIndexFormat ArrayIndexFormat `json:"arrayIndexFormat"`
+	// The part of a flattened key used for arrays nested directly within exploded arrays. A blank value is the same as "_"
+	NestedArrayMarker string `json:"nestedArrayMarker"`
 	// the time path relative to the data path.
-	TimeFields   []TimeField   `json:"timeFields"`
-	LabelOptions []LabelOption `json:"labelOptions"`
+	TimeFields []TimeField `json:"timeFields"`
+	// When true, fields that look like timestamps are treated as time fields in addition to TimeFields
+	AutoDetectTimeFields bool `json:"autoDetectTimeFields"`
+	// Fields computed from the other fields of each row. They may be used like any other field, including by labels and time fields
+	ComputedFields []ComputedField `json:"computedFields"`
+	// An expr expression that each row must match, such as `status == "ok" && value > 0`. Rows that do not match are dropped.
+	//   Computed fields may be used in the expression. A blank value keeps every row.
+	RowFilter string `json:"rowFilter"`
+	// The type of numeric fields. A blank value is the same as FLOAT64
+	NumberType NumberType `json:"numberType"`
+	// Numeric options for individual fields, which take precedence over NumberType
+	NumberFields []NumberField `json:"numberFields"`
+	// Type hints for individual fields. Values of a hinted field are coerced to that type
+	FieldTypes []FieldTypeHint `json:"fieldTypes"`
+	// What happens when a field has values of different types. A blank value is the same as MIXED_TYPE_ERROR
+	MixedTypePolicy MixedTypePolicy `json:"mixedTypePolicy"`
+	// The template used to name frames and value fields, such as "{{host}} {{__field}}". A blank value uses the default names.
+	//   {{__field}} is the name of the field, {{__refId}} is the refId of the query, {{__labels}} is every label, and any other placeholder is the name of a label
+	NameTemplate string `json:"nameTemplate"`
+	// Tables that map the string or boolean values of individual fields to numbers or other strings
+	ValueMaps []ValueMap `json:"valueMaps"`
+	// Fields derived from other fields of the same series, such as the rate of a counter
+	DerivedFields []DerivedField `json:"derivedFields"`
+	// How series with more points than the max data points of the query are downsampled. A blank value is the same as NO_DOWNSAMPLING
+	Downsampling DownsampleMethod `json:"downsampling"`
+	// The field used by LTTB and MIN_MAX to choose points. A blank value uses the first numeric field
+	DownsampleFieldPath string `json:"downsampleFieldPath"`
+	// The order of the rows of each series. A blank value is the same as NO_SORT
+	SortOrder SortOrder `json:"sortOrder"`
+	// The field each series is sorted by. A blank value uses the first time field
+	SortFieldPath string `json:"sortFieldPath"`
+	// Which duplicate rows of each series are dropped. A blank value is the same as KEEP_DUPLICATES
+	DuplicatePolicy DuplicatePolicy `json:"duplicatePolicy"`
+	// The maximum number of rows of each series, or nil for no limit. This is applied after sorting, deduplication and downsampling
+	RowsPerSeries *int `json:"rowsPerSeries"`
+	// Whether the first or last rows of each series are kept when there are more than RowsPerSeries. A blank value is the same as FIRST_ROWS
+	RowsPerSeriesFrom RowsFrom `json:"rowsPerSeriesFrom"`
+	// The maximum number of series (sets of labels), or nil for no limit
+	SeriesLimit *int `json:"seriesLimit"`
+	// Whether the series with the highest or lowest aggregate are kept. A blank value is the same as TOP_SERIES
+	SeriesLimitRank SeriesRank `json:"seriesLimitRank"`
+	// The field series are ranked by. A blank value uses the first numeric field
+	SeriesLimitFieldPath string `json:"seriesLimitFieldPath"`
+	// A blank value is the same as AGGREGATE_LAST
+	SeriesLimitAggregate AggregateType `json:"seriesLimitAggregate"`
+	// When true, the series that are not kept are merged into a single series labeled "other". Otherwise, they are dropped
+	MergeOtherSeries bool `json:"mergeOtherSeries"`
+	// Display options for individual fields, which are sent to Grafana as part of the field config
+	FieldConfigs []FieldConfig `json:"fieldConfigs"`
+	// Patterns of fields to include in the data frame. When empty, every field is included.
+	//   Patterns are globs that may contain the same wildcards as ExplodeArrayPaths, or regular expressions surrounded by "/", such as "/^value.*/"
+	IncludeFields []string `json:"includeFields"`
+	// Patterns of fields to exclude from the data frame, which take precedence over IncludeFields. Excluded fields may still be used by labels
+	ExcludeFields []string      `json:"excludeFields"`
+	LabelOptions  []LabelOption `json:"labelOptions"`
 }
 
 type TimeField struct {
 	TimePath string `json:"timePath"`
+	// The format used to parse string values. This may be a Go layout such as "2006-01-02 15:04:05",
+	//   the name of a well known format such as "RFC3339Nano", or "epoch" to parse strings that contain a number.
+	//   A blank value is the same as RFC3339.
+	TimeFormat string `json:"timeFormat"`
+	// The unit of numeric values, and of string values using the "epoch" format. A blank value is the same as MILLISECONDS
+	TimeUnit TimeUnit `json:"timeUnit"`
+	// The IANA time zone, such as "America/Chicago", used for string values that do not contain time zone information.
+	//   A blank value is the same as UTC.
+	TimeZone string `json:"timeZone"`
+}
+
+type ExplodeIndexMode string
+
+const (
+	// NO_INDEX only makes the index available to label options, using the "<path>#index" key
+	NO_INDEX ExplodeIndexMode = "none"
+	// INDEX_AS_FIELD adds a numeric "<path>#index" field
+	INDEX_AS_FIELD ExplodeIndexMode = "field"
+	// INDEX_AS_LABEL adds a "<path>#index" label
+	INDEX_AS_LABEL ExplodeIndexMode = "label"
+)
+
+type TimeUnit string
+
+const (
+	SECONDS      TimeUnit = "s"
+	MILLISECONDS TimeUnit = "ms"
+	MICROSECONDS TimeUnit = "us"
+	NANOSECONDS  TimeUnit = "ns"
+)
+
+type ExplodeMode string
+
+const (
+	// CROSS combines sibling exploded arrays by taking the Cartesian product of their elements
+	CROSS ExplodeMode = "cross"
+	// ZIP combines sibling exploded arrays index by index. This is useful for columnar data such as {timestamps: [...], values: [...]}
+	ZIP ExplodeMode = "zip"
+)
+
+type ScalarArrayMode string
+
+const (
+	// FLATTEN_SCALARS creates a field for each element, such as "tags.0" and "tags.1"
+	FLATTEN_SCALARS ScalarArrayMode = "flatten"
+	// JOIN_SCALARS joins the elements into a single string field, such as "a,b"
+	JOIN_SCALARS ScalarArrayMode = "join"
+	// SCALARS_AS_JSON keeps the elements as a single JSON array field, such as ["a","b"]
+	SCALARS_AS_JSON ScalarArrayMode = "json"
+)
+
+type ArrayIndexFormat string
+
+const (
+	// SEPARATOR_INDEX formats array indexes like other parts of a key, such as "values.0.name"
+	SEPARATOR_INDEX ArrayIndexFormat = "separator"
+	// BRACKET_INDEX formats array indexes within brackets, such as "values[0].name"
+	BRACKET_INDEX ArrayIndexFormat = "bracket"
+)
+
+type NumberType string
+
+const (
+	// FLOAT64 is the default type of numbers, which loses precision for integers larger than 2^53
+	FLOAT64 NumberType = "float64"
+	INT64   NumberType = "int64"
+	UINT64  NumberType = "uint64"
+	// AUTO uses int64 if every value of a field is an integer that fits, then uint64, and float64 otherwise
+	AUTO NumberType = "auto"
+)
+
+type NumberField struct {
+	FieldPath string `json:"fieldPath"`
+	// The type of this field. A blank value is the same as the NumberType of the parsing option
+	NumberType NumberType `json:"numberType"`
+	// When true, string values are parsed as numbers. This is useful for custom scalars such as BigInt or Long that are serialized as strings
+	ParseStrings bool `json:"parseStrings"`
+	// Each value is multiplied by Scale, then Offset is added to it. This is useful to convert values such as millivolts or cents into base units.
+	//   A nil Scale is the same as 1, and a nil Offset is the same as 0
+	Scale  *float64 `json:"scale"`
+	Offset *float64 `json:"offset"`
+}
+
+type ComputedField struct {
+	// The name of the computed field
+	Name string `json:"name"`
+	// An expression written in the expr language (https://expr-lang.org), such as "voltage * current".
+	//   Fields of the row are variables, where nested fields may be referred to like packet.voltage.
+	Expression string `json:"expression"`
+}
+
+type FieldType string
+
+const (
+	STRING FieldType = "string"
+	// NUMBER uses the number type of the field
+	NUMBER FieldType = "number"
+	INT    FieldType = "int"
+	BOOL   FieldType = "bool"
+	// TIME parses the field the same way as a TimeField with default options
+	TIME FieldType = "time"
+	// JSON stores the raw JSON text of each value, such as 1.5 or "a"
+	JSON FieldType = "json"
+	// ENUM stores the field as an enum with one value for each distinct string
+	ENUM FieldType = "enum"
+)
+
+type FieldTypeHint struct {
+	FieldPath string    `json:"fieldPath"`
+	Type      FieldType `json:"type"`
+}
+
+type MixedTypePolicy string
+
+const (
+	// MIXED_TYPE_ERROR returns an error naming the field and the first row with a different type
+	MIXED_TYPE_ERROR MixedTypePolicy = "error"
+	// PROMOTE_TO_STRING converts every value of a field with mixed types to a string
+	PROMOTE_TO_STRING MixedTypePolicy = "string"
+	// PROMOTE_TO_JSON converts every value of a field with mixed types to raw JSON
+	PROMOTE_TO_JSON MixedTypePolicy = "json"
+)
+
+type FieldConfig struct {
+	FieldPath string `json:"fieldPath"`
+	// The name of the field in the data frame. A blank value uses the field path
+	Alias string `json:"alias"`
+	// The name shown in legends and table headers. A blank value lets Grafana decide
+	DisplayName string `json:"displayName"`
+	// The unit, such as "volt" or "percent". See https://github.com/grafana/grafana/blob/main/packages/grafana-data/src/valueFormats/categories.ts
+	Unit string `json:"unit"`
+	// The number of decimal places to display, or nil to let Grafana decide
+	Decimals    *uint16  `json:"decimals"`
+	Min         *float64 `json:"min"`
+	Max         *float64 `json:"max"`
+	Description string   `json:"description"`
+}
+
+type ValueMap struct {
+	FieldPath string         `json:"fieldPath"`
+	Mappings  []ValueMapping `json:"mappings"`
+	// When true, values without a mapping become null, which keeps a field that is mapped to numbers numeric
+	NullUnmappedValues bool `json:"nullUnmappedValues"`
+	// When true, Grafana value mappings are added to the field config so that mapped values are displayed as their original value
+	EmitFieldMappings bool `json:"emitFieldMappings"`
+}
+type ValueMapping struct {
+	// The value to map. Booleans are mapped using "true" and "false"
+	Value string `json:"value"`
+	// The number the value is mapped to. Exactly one of Number and Text must be set
+	Number *float64 `json:"number"`
+	// The string the value is mapped to. Exactly one of Number and Text must be set
+	Text *string `json:"text"`
+	// The text displayed by the Grafana value mapping. A blank value uses Value
+	DisplayText string `json:"displayText"`
+	// The color used by the Grafana value mapping, such as "green" or "#FF0000". A blank value lets Grafana decide
+	Color string `json:"color"`
+}
+
+type DownsampleMethod string
+
+const (
+	NO_DOWNSAMPLING DownsampleMethod = "none"
+	// LTTB keeps the points chosen by the Largest-Triangle-Three-Buckets algorithm, which preserves the shape of the series
+	LTTB DownsampleMethod = "lttb"
+	// MEAN replaces the points of each interval with their mean
+	MEAN DownsampleMethod = "mean"
+	// MIN_MAX keeps the points with the minimum and maximum value of each interval
+	MIN_MAX DownsampleMethod = "minMax"
+	// LAST keeps the last point of each interval
+	LAST DownsampleMethod = "last"
+)
+
+type SeriesRank string
+
+const (
+	TOP_SERIES    SeriesRank = "top"
+	BOTTOM_SERIES SeriesRank = "bottom"
+)
+
+type AggregateType string
+
+const (
+	AGGREGATE_LAST AggregateType = "last"
+	AGGREGATE_MAX  AggregateType = "max"
+	AGGREGATE_MEAN AggregateType = "mean"
+	AGGREGATE_SUM  AggregateType = "sum"
+)
+
+type SortOrder string
+
+const (
+	NO_SORT    SortOrder = "none"
+	ASCENDING  SortOrder = "ascending"
+	DESCENDING SortOrder = "descending"
+)
+
+type DuplicatePolicy string
+
+const (
+	KEEP_DUPLICATES DuplicatePolicy = "keep"
+	// DROP_EXACT_DUPLICATES keeps the first of the rows that have the same value for every field
+	DROP_EXACT_DUPLICATES DuplicatePolicy = "exact"
+	// DROP_SAME_TIME keeps the last of the rows that have the same time
+	DROP_SAME_TIME DuplicatePolicy = "time"
+)
+
+type RowsFrom string
+
+const (
+	FIRST_ROWS RowsFrom = "first"
+	LAST_ROWS  RowsFrom = "last"
+)
+
+type DerivedFieldType string
 
-	// We will put time format options here as the frontend implements it
+const (
+	// DELTA is the difference between a value and the previous value of the same series
+	DELTA DerivedFieldType = "delta"
+	// RATE is the difference between a value and the previous value of the same series per second
+	RATE DerivedFieldType = "rate"
+	// NON_NEGATIVE_DERIVATIVE is the same as RATE, but negative values are null
+	NON_NEGATIVE_DERIVATIVE DerivedFieldType = "nonNegativeDerivative"
+)
+
+type DerivedField struct {
+	// The name of the derived field
+	Name string `json:"name"`
+	// The field the values are derived from
+	FieldPath string           `json:"fieldPath"`
+	Type      DerivedFieldType `json:"type"`
+	// The time field that rows are ordered by. A blank value uses the first time field of the parsing option
+	TimePath string `json:"timePath"`
+	// When true, a value that is less than the previous value is treated as a counter that was reset to zero
+	CounterResets bool `json:"counterResets"`
 }
 
 type LabelOptionType string
@@ -58,6 +370,45 @@ func (parsingOption *ParsingOption) GetTimeField(key string) *TimeField {
 	return nil
 }
 
+func (parsingOption *ParsingOption) GetNumberField(key string) *NumberField {
+	for _, numberField := range parsingOption.NumberFields {
+		if numberField.FieldPath == key {
+			return &numberField
+		}
+	}
+	return nil
+}
+
+func (parsingOption *ParsingOption) GetFieldTypeHint(key string) *FieldTypeHint {
+	for _, fieldTypeHint := range parsingOption.FieldTypes {
+		if fieldTypeHint.FieldPath == key {
+			return &fieldTypeHint
+		}
+	}
+	return nil
+}
+
+func (parsingOption *ParsingOption) GetValueMap(key string) *ValueMap {
+	for _, valueMap := range parsingOption.ValueMaps {
+		if valueMap.FieldPath == key {
+			return &valueMap
+		}
+	}
+	return nil
+}
+
+// GetNumberType returns the type of the number field at the given key
+func (parsingOption *ParsingOption) GetNumberType(key string) NumberType {
+	numberField := parsingOption.GetNumberField(key)
+	if numberField != nil && numberField.NumberType != "" {
+		return numberField.NumberType
+	}
+	if parsingOption.NumberType != "" {
+		return parsingOption.NumberType
+	}
+	return FLOAT64
+}
+
 // GetFieldsExcludedFromDataFrame returns all fields that should be excluded from the data frame.
 // The current implementation determines this by only looking at the label options,
 // but future changes to the behavior may change this.
@@ -52,6 +52,9 @@ func (a *Array) Serialize() json.RawMessage {
 func (a *Array) Marshal() ([]byte, error) {
 	return a.Serialize(), nil
 }
+func (a *Array) MarshalJSON() ([]byte, error) {
+	return a.Serialize(), nil
+}
 
 func (a *Array) decodeJSON(startToken json.Token, decoder *json.Decoder) error {
 	if startToken != json.Delim('[') {
@@ -0,0 +1,169 @@
+package jsonnode
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+// NumberHandling determines how ToAny converts a Number into a native Go value
+type NumberHandling int
+
+const (
+	// NUMBER_AS_JSON_NUMBER keeps numbers as json.Number, which never loses precision
+	NUMBER_AS_JSON_NUMBER NumberHandling = 0
+	// NUMBER_AS_FLOAT64 converts every number to a float64, which is what encoding/json does by default
+	NUMBER_AS_FLOAT64 NumberHandling = 1
+	// NUMBER_AS_INT64_OR_FLOAT64 converts integers that fit into an int64 to int64, and everything else to float64
+	NUMBER_AS_INT64_OR_FLOAT64 NumberHandling = 2
+)
+
+// ToAny converts a node into plain Go values, the same values that encoding/json would produce when decoding into an any.
+// Objects become map[string]any, arrays become []any, and Null becomes nil.
+// Note that map[string]any does not maintain the order of keys, so prefer keeping an *Object around if order matters.
+func ToAny(node Node, numberHandling NumberHandling) (any, error) {
+	switch typedNode := node.(type) {
+	case *Object:
+		r := make(map[string]any, len(typedNode.Keys()))
+		for _, key := range typedNode.Keys() {
+			value, err := ToAny(typedNode.Get(key), numberHandling)
+			if err != nil {
+				return nil, err
+			}
+			r[key] = value
+		}
+		return r, nil
+	case *Array:
+		r := make([]any, len(*typedNode))
+		for i, element := range *typedNode {
+			value, err := ToAny(element, numberHandling)
+			if err != nil {
+				return nil, err
+			}
+			r[i] = value
+		}
+		return r, nil
+	case Number:
+		return numberToAny(typedNode, numberHandling)
+	case Boolean:
+		return typedNode.Bool(), nil
+	case String:
+		return typedNode.String(), nil
+	case Null, nil:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown node type: %v", reflect.TypeOf(node))
+}
+
+func numberToAny(number Number, numberHandling NumberHandling) (any, error) {
+	switch numberHandling {
+	case NUMBER_AS_JSON_NUMBER:
+		return number.Number(), nil
+	case NUMBER_AS_FLOAT64:
+		return number.Float64()
+	case NUMBER_AS_INT64_OR_FLOAT64:
+		if value, err := number.Int64(); err == nil {
+			return value, nil
+		}
+		return number.Float64()
+	}
+	return nil, fmt.Errorf("unknown number handling: %d", numberHandling)
+}
+
+// FromAny converts plain Go values into a Node.
+// Maps are converted to objects with their keys sorted, as a map has no order of its own.
+// Types that are not directly supported are converted by marshalling them with encoding/json,
+// so structs and types that implement json.Marshaler are supported as well.
+func FromAny(value any) (Node, error) {
+	switch typedValue := value.(type) {
+	case nil:
+		return NULL, nil
+	case Node:
+		return typedValue.DeepCopy(), nil
+	case json.Number:
+		return parseNumber(typedValue.String())
+	case json.RawMessage:
+		return Decode(typedValue)
+	case bool:
+		return Boolean(typedValue), nil
+	case string:
+		return String(typedValue), nil
+	case float64:
+		return floatToNumber(typedValue)
+	case float32:
+		return floatToNumber(typedValue)
+	case int:
+		return Number(strconv.FormatInt(int64(typedValue), 10)), nil
+	case int8:
+		return Number(strconv.FormatInt(int64(typedValue), 10)), nil
+	case int16:
+		return Number(strconv.FormatInt(int64(typedValue), 10)), nil
+	case int32:
+		return Number(strconv.FormatInt(int64(typedValue), 10)), nil
+	case int64:
+		return Number(strconv.FormatInt(typedValue, 10)), nil
+	case uint:
+		return Number(strconv.FormatUint(uint64(typedValue), 10)), nil
+	case uint8:
+		return Number(strconv.FormatUint(uint64(typedValue), 10)), nil
+	case uint16:
+		return Number(strconv.FormatUint(uint64(typedValue), 10)), nil
+	case uint32:
+		return Number(strconv.FormatUint(uint64(typedValue), 10)), nil
+	case uint64:
+		return Number(strconv.FormatUint(typedValue, 10)), nil
+	case map[string]any:
+		keys := make([]string, 0, len(typedValue))
+		for key := range typedValue {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		object := NewObject()
+		for _, key := range keys {
+			node, err := FromAny(typedValue[key])
+			if err != nil {
+				return nil, err
+			}
+			object.Put(key, node)
+		}
+		return object, nil
+	case []any:
+		array := make(Array, len(typedValue))
+		for i, element := range typedValue {
+			node, err := FromAny(element)
+			if err != nil {
+				return nil, err
+			}
+			array[i] = node
+		}
+		return &array, nil
+	}
+	marshalled, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("could not convert value of type %v to a node: %w", reflect.TypeOf(value), err)
+	}
+	return Decode(marshalled)
+}
+
+func parseNumber(literal string) (Node, error) {
+	node, err := Decode([]byte(literal))
+	if err != nil {
+		return nil, fmt.Errorf("invalid number: %s", literal)
+	}
+	if _, ok := node.(Number); !ok {
+		return nil, fmt.Errorf("invalid number: %s", literal)
+	}
+	return node, nil
+}
+
+// floatToNumber converts a float32 or float64 to a Number using the same formatting as encoding/json.
+// NaN and infinite values cannot be represented in JSON and result in an error.
+func floatToNumber(value any) (Node, error) {
+	marshalled, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return Number(marshalled), nil
+}
@@ -1,6 +1,7 @@
 package jsonnode
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -33,3 +34,21 @@ func decodeNode(token json.Token, decoder *json.Decoder) (Node, error) {
 	}
 	return nil, fmt.Errorf("unknown token: %v", token)
 }
+
+// Decode parses a single JSON value of any type into a Node.
+// Numbers are kept as Number so that no precision is lost.
+func Decode(data []byte) (Node, error) {
+	d := createDecoder(bytes.NewReader(data))
+	token, err := d.Token()
+	if err != nil {
+		return nil, err
+	}
+	node, err := decodeNode(token, d)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after top-level JSON value")
+	}
+	return node, nil
+}
@@ -0,0 +1,58 @@
+package jsonnode
+
+import "math/big"
+
+// Equal determines whether two nodes represent the same JSON value.
+// The order of keys within an object is not considered, as JSON objects are unordered.
+// Numbers are compared by their numeric value, so 5 and 5.0 are equal.
+func Equal(a Node, b Node) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	switch typedA := a.(type) {
+	case *Object:
+		typedB, ok := b.(*Object)
+		if !ok || len(typedA.Keys()) != len(typedB.Keys()) {
+			return false
+		}
+		for _, key := range typedA.Keys() {
+			if !typedB.KeyExists(key) || !Equal(typedA.Get(key), typedB.Get(key)) {
+				return false
+			}
+		}
+		return true
+	case *Array:
+		typedB, ok := b.(*Array)
+		if !ok || len(*typedA) != len(*typedB) {
+			return false
+		}
+		for i := range *typedA {
+			if !Equal((*typedA)[i], (*typedB)[i]) {
+				return false
+			}
+		}
+		return true
+	case Number:
+		typedB, ok := b.(Number)
+		if !ok {
+			return false
+		}
+		if typedA == typedB {
+			return true
+		}
+		// big.Rat parses decimal and exponent notation exactly, so no precision is lost when comparing
+		ratA, okA := new(big.Rat).SetString(typedA.String())
+		ratB, okB := new(big.Rat).SetString(typedB.String())
+		return okA && okB && ratA.Cmp(ratB) == 0
+	case Boolean:
+		typedB, ok := b.(Boolean)
+		return ok && typedA == typedB
+	case String:
+		typedB, ok := b.(String)
+		return ok && typedA == typedB
+	case Null:
+		_, ok := b.(Null)
+		return ok
+	}
+	return false
+}
@@ -180,3 +180,110 @@ func TestStringSerialize(t *testing.T) {
 		t.Fatal("Incorrect string serialization")
 	}
 }
+
+func TestMarshalJSONRoundTrip(t *testing.T) {
+	jsonString := `{"b":[1,2.50,{"c":null}],"a":"asdf","d":true,"e":12345678901234567890}`
+	var object = NewObject()
+	err := json.Unmarshal([]byte(jsonString), &object)
+	if err != nil {
+		t.Fatal("Could not unmarshal JSON", err)
+	}
+	marshalled, err := json.Marshal(object)
+	if err != nil {
+		t.Fatal("Could not marshal JSON", err)
+	}
+	if string(marshalled) != jsonString {
+		t.Errorf("Unexpected JSON. Got: %s", marshalled)
+	}
+
+	var number Number
+	if err := json.Unmarshal([]byte(`12345678901234567890`), &number); err != nil || number != "12345678901234567890" {
+		t.Errorf("Could not unmarshal number. number: %s err: %v", number, err)
+	}
+	var str String
+	if err := json.Unmarshal([]byte(`12`), &str); err == nil {
+		t.Error("We expect an error when unmarshalling a number into a String")
+	}
+	if _, err := json.Marshal(Number("not a number")); err == nil {
+		t.Error("We expect an error when marshalling an invalid Number")
+	}
+}
+
+func TestFromAnyAndToAny(t *testing.T) {
+	node, err := FromAny(map[string]any{
+		"b":      []any{1, 2.5, nil},
+		"a":      "asdf",
+		"bool":   true,
+		"number": json.Number("9223372036854775807"),
+		"uint":   uint64(18446744073709551615),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"a":"asdf","b":[1,2.5,null],"bool":true,"number":9223372036854775807,"uint":18446744073709551615}`
+	if string(node.Serialize()) != expected {
+		t.Errorf("Unexpected serialization from FromAny: %s", node.Serialize())
+	}
+
+	value, err := ToAny(node, NUMBER_AS_INT64_OR_FLOAT64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valueMap := value.(map[string]any)
+	if valueMap["number"] != int64(9223372036854775807) {
+		t.Errorf("Unexpected value for number: %#v", valueMap["number"])
+	}
+	if valueMap["uint"] != float64(18446744073709551615) {
+		t.Errorf("Unexpected value for uint: %#v", valueMap["uint"])
+	}
+	array := valueMap["b"].([]any)
+	if array[0] != int64(1) || array[1] != 2.5 || array[2] != nil {
+		t.Errorf("Unexpected value for b: %#v", array)
+	}
+
+	value, err = ToAny(node, NUMBER_AS_JSON_NUMBER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value.(map[string]any)["uint"] != json.Number("18446744073709551615") {
+		t.Errorf("Unexpected value for uint: %#v", value.(map[string]any)["uint"])
+	}
+
+	type example struct {
+		Name string `json:"name"`
+	}
+	structNode, err := FromAny(example{Name: "asdf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(structNode.Serialize()) != `{"name":"asdf"}` {
+		t.Errorf("Unexpected serialization of struct: %s", structNode.Serialize())
+	}
+}
+
+func TestEqual(t *testing.T) {
+	a, err := Decode([]byte(`{"a": 5, "b": [1, "2", null, true]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Decode([]byte(`{"b": [1.0, "2", null, true], "a": 5e0}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Equal(a, b) {
+		t.Error("Expected a and b to be equal")
+	}
+	if !Equal(a, a.DeepCopy()) {
+		t.Error("Expected a and its copy to be equal")
+	}
+	c, err := Decode([]byte(`{"a": 5, "b": [1, 2, null, true]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Equal(a, c) {
+		t.Error("Expected a and c to not be equal")
+	}
+	if Equal(String("5"), Number("5")) {
+		t.Error("A string should never equal a number")
+	}
+}
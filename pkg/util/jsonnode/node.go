@@ -9,4 +9,5 @@ type Node interface {
 	String() string
 	Serialize() json.RawMessage
 	Marshal() ([]byte, error) // extends Marshaler
+	MarshalJSON() ([]byte, error)
 }
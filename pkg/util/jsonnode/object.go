@@ -115,6 +115,9 @@ func (o *Object) Serialize() json.RawMessage {
 func (o *Object) Marshal() ([]byte, error) {
 	return o.Serialize(), nil
 }
+func (o *Object) MarshalJSON() ([]byte, error) {
+	return o.Serialize(), nil
+}
 
 func (o *Object) Keys() []string {
 	return o.order
@@ -21,6 +21,22 @@ func (n Number) Serialize() json.RawMessage {
 func (n Number) Marshal() ([]byte, error) {
 	return n.Serialize(), nil
 }
+func (n Number) MarshalJSON() ([]byte, error) {
+	// json.Number validates the literal for us, so an invalid Number results in an error rather than invalid JSON
+	return json.Marshal(n.Number())
+}
+func (n *Number) UnmarshalJSON(data []byte) error {
+	node, err := Decode(data)
+	if err != nil {
+		return err
+	}
+	typedNode, ok := node.(Number)
+	if !ok {
+		return fmt.Errorf("JSON value is not a number! value: %s", data)
+	}
+	*n = typedNode
+	return nil
+}
 
 func (n Number) Number() json.Number {
 	return json.Number(n)
@@ -56,6 +72,21 @@ func (b Boolean) Serialize() json.RawMessage {
 func (b Boolean) Marshal() ([]byte, error) {
 	return b.Serialize(), nil
 }
+func (b Boolean) MarshalJSON() ([]byte, error) {
+	return b.Serialize(), nil
+}
+func (b *Boolean) UnmarshalJSON(data []byte) error {
+	node, err := Decode(data)
+	if err != nil {
+		return err
+	}
+	typedNode, ok := node.(Boolean)
+	if !ok {
+		return fmt.Errorf("JSON value is not a boolean! value: %s", data)
+	}
+	*b = typedNode
+	return nil
+}
 func (b Boolean) Bool() bool {
 	return bool(b)
 }
@@ -80,6 +111,21 @@ func (s String) Serialize() json.RawMessage {
 func (s String) Marshal() ([]byte, error) {
 	return s.Serialize(), nil
 }
+func (s String) MarshalJSON() ([]byte, error) {
+	return s.Serialize(), nil
+}
+func (s *String) UnmarshalJSON(data []byte) error {
+	node, err := Decode(data)
+	if err != nil {
+		return err
+	}
+	typedNode, ok := node.(String)
+	if !ok {
+		return fmt.Errorf("JSON value is not a string! value: %s", data)
+	}
+	*s = typedNode
+	return nil
+}
 
 type Null bool
 
@@ -99,6 +145,20 @@ func (n Null) Serialize() json.RawMessage {
 func (n Null) Marshal() ([]byte, error) {
 	return n.Serialize(), nil
 }
+func (n Null) MarshalJSON() ([]byte, error) {
+	return n.Serialize(), nil
+}
+func (n *Null) UnmarshalJSON(data []byte) error {
+	node, err := Decode(data)
+	if err != nil {
+		return err
+	}
+	if _, ok := node.(Null); !ok {
+		return fmt.Errorf("JSON value is not null! value: %s", data)
+	}
+	*n = NULL
+	return nil
+}
 
 func parsePrimitive(token json.Token) Node {
 	switch typedToken := token.(type) {
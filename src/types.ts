@@ -3,7 +3,18 @@ import { DataQuery } from '@grafana/schema';
 
 export interface TimeField {
   timePath: string;
-  // TODO add a time format option here
+  /** The format used to parse string values. This may be a Go layout, the name of a well known format such as "RFC3339Nano", or "epoch". An undefined value is the same as RFC3339 */
+  timeFormat?: string;
+  /** The unit of numeric values. An undefined value is the same as {@link TimeUnit.MILLISECONDS} */
+  timeUnit?: TimeUnit;
+  /** The IANA time zone used for string values without time zone information. An undefined value is the same as UTC */
+  timeZone?: string;
+}
+export enum TimeUnit {
+  SECONDS = "s",
+  MILLISECONDS = "ms",
+  MICROSECONDS = "us",
+  NANOSECONDS = "ns",
 }
 
 export interface LabelOption {
@@ -29,16 +40,271 @@ export const DEFAULT_LABEL_OPTION_FIELD_CONFIG: LabelOptionFieldConfig = { // om
   required: false
 }
 
+export enum ExplodeMode {
+  /** Combines sibling exploded arrays by taking the Cartesian product of their elements */
+  CROSS = "cross",
+  /** Combines sibling exploded arrays index by index */
+  ZIP = "zip",
+}
+
+export enum ScalarArrayMode {
+  /** Such as tags.0 and tags.1 */
+  FLATTEN = "flatten",
+  /** Such as "a,b" */
+  JOIN = "join",
+  /** Such as ["a","b"] */
+  JSON = "json",
+}
+
+export enum ArrayIndexFormat {
+  /** Such as values.0.name */
+  SEPARATOR = "separator",
+  /** Such as values[0].name */
+  BRACKET = "bracket",
+}
+
+export enum ExplodeIndexMode {
+  /** The "<path>#index" key may only be used by field labels */
+  NONE = "none",
+  FIELD = "field",
+  LABEL = "label",
+}
+
+export enum NumberType {
+  FLOAT64 = "float64",
+  INT64 = "int64",
+  UINT64 = "uint64",
+  /** int64 if every value of a field is an integer that fits, then uint64, and float64 otherwise */
+  AUTO = "auto",
+}
+
+export enum FieldType {
+  STRING = "string",
+  NUMBER = "number",
+  INT = "int",
+  BOOL = "bool",
+  TIME = "time",
+  JSON = "json",
+  ENUM = "enum",
+}
+
+export enum MixedTypePolicy {
+  /** Returns an error naming the field and the first row with a different type */
+  ERROR = "error",
+  PROMOTE_TO_STRING = "string",
+  PROMOTE_TO_JSON = "json",
+}
+
+export interface FieldTypeHint {
+  fieldPath: string;
+  type: FieldType;
+}
+
+export interface ComputedField {
+  name: string;
+  /** An expression written in the expr language (https://expr-lang.org), such as "voltage * current" */
+  expression: string;
+}
+
+export enum DownsampleMethod {
+  NONE = 'none',
+  /** Keeps the points chosen by the Largest-Triangle-Three-Buckets algorithm, which preserves the shape of the series */
+  LTTB = 'lttb',
+  /** Replaces the points of each interval with their mean */
+  MEAN = 'mean',
+  /** Keeps the points with the minimum and maximum value of each interval */
+  MIN_MAX = 'minMax',
+  /** Keeps the last point of each interval */
+  LAST = 'last',
+}
+
+export enum SeriesRank {
+  TOP = 'top',
+  BOTTOM = 'bottom',
+}
+
+export enum AggregateType {
+  LAST = 'last',
+  MAX = 'max',
+  MEAN = 'mean',
+  SUM = 'sum',
+}
+
+export enum SortOrder {
+  NONE = 'none',
+  ASCENDING = 'ascending',
+  DESCENDING = 'descending',
+}
+
+export enum DuplicatePolicy {
+  KEEP = 'keep',
+  /** Keeps the first of the rows that have the same value for every field */
+  DROP_EXACT = 'exact',
+  /** Keeps the last of the rows that have the same time */
+  DROP_SAME_TIME = 'time',
+}
+
+export enum RowsFrom {
+  FIRST = 'first',
+  LAST = 'last',
+}
+
+export enum DerivedFieldType {
+  /** The difference between a value and the previous value of the same series */
+  DELTA = 'delta',
+  /** The difference between a value and the previous value of the same series per second */
+  RATE = 'rate',
+  /** The same as {@link RATE}, but negative values are null */
+  NON_NEGATIVE_DERIVATIVE = 'nonNegativeDerivative',
+}
+
+export interface DerivedField {
+  name: string;
+  fieldPath: string;
+  /** An undefined value is the same as {@link DerivedFieldType.DELTA} */
+  type?: DerivedFieldType;
+  /** The time field that rows are ordered by. An undefined value uses the first time field of the parsing option */
+  timePath?: string;
+  /** When true, a value that is less than the previous value is treated as a counter that was reset to zero */
+  counterResets?: boolean;
+}
+
+export interface ValueMapping {
+  /** The value to map. Booleans are mapped using "true" and "false", and numbers are matched by value, so "1" matches 1.0 */
+  value: string;
+  /** The number the value is mapped to. Exactly one of {@link number} and {@link text} must be defined */
+  number?: number;
+  /** The string the value is mapped to. Exactly one of {@link number} and {@link text} must be defined */
+  text?: string;
+  /** The text displayed by the Grafana value mapping. An undefined value uses {@link value} */
+  displayText?: string;
+  color?: string;
+}
+
+export interface ValueMap {
+  fieldPath: string;
+  mappings: ValueMapping[];
+  /** When true, values without a mapping become null */
+  nullUnmappedValues?: boolean;
+  /** When true, Grafana value mappings are added to the field config so that mapped values are displayed as their original value */
+  emitFieldMappings?: boolean;
+}
+
+export interface FieldConfig {
+  fieldPath: string;
+  /** The name of the field in the data frame. An undefined value uses the field path */
+  alias?: string;
+  /** The name shown in legends and table headers */
+  displayName?: string;
+  unit?: string;
+  decimals?: number;
+  min?: number;
+  max?: number;
+  description?: string;
+}
+
+export interface NumberField {
+  fieldPath: string;
+  /** An undefined value is the same as the number type of the parsing option */
+  numberType?: NumberType;
+  /** When true, string values are parsed as numbers. Useful for custom scalars such as BigInt or Long */
+  parseStrings?: boolean;
+  /** Each value is multiplied by this. An undefined value is the same as 1 */
+  scale?: number;
+  /** Added to each value after it is scaled. An undefined value is the same as 0 */
+  offset?: number;
+}
+
 export interface ParsingOption {
-  /** Required. The path to the array of data or object of data. Note: An empty string is valid -- it refers to the top-most data */
+  /** Required. The path to the array of data or object of data. Note: An empty string is valid -- it refers to the top-most data.
+   * This may also be a JSONPath-style expression such as `items[*].readings`, in which case the data of every match is concatenated. */
   dataPath: string;
+  /** Paths to nested arrays that should be exploded into multiple rows. A `*` matches any characters within a single part of the path, and a `**` matches any number of parts. */
   explodeArrayPaths?: string[];
+  /** How sibling arrays that are exploded are combined. An undefined value is the same as {@link ExplodeMode.CROSS} */
+  explodeMode?: ExplodeMode;
+  /** The maximum number of rows this parsing option may produce. An undefined value uses the default limit of the backend. */
+  maxRows?: number;
+  /** Whether the index of each exploded array element is exposed as a field or label. An undefined value is the same as {@link ExplodeIndexMode.NONE} */
+  explodeIndexMode?: ExplodeIndexMode;
+  /** When true, the indexes of exploded arrays that contain other exploded arrays are also exposed */
+  explodeParentIndexes?: boolean;
+  /** The number of levels of nested objects and arrays that are flattened. Deeper values are kept as JSON. An undefined value flattens everything. */
+  flattenDepth?: number;
+  /** Paths to nested objects and arrays that are kept as a single JSON field rather than being flattened */
+  keepJsonPaths?: string[];
+  /** Whether arrays of scalars are flattened or combined into a single field. An undefined value is the same as {@link ScalarArrayMode.FLATTEN} */
+  scalarArrayMode?: ScalarArrayMode;
+  /** The delimiter used by {@link ScalarArrayMode.JOIN}. An undefined value is the same as "," */
+  scalarArrayDelimiter?: string;
+  /** The separator between the parts of flattened keys. An undefined value is the same as "." */
+  keySeparator?: string;
+  /** How array indexes appear in flattened keys. An undefined value is the same as {@link ArrayIndexFormat.SEPARATOR} */
+  arrayIndexFormat?: ArrayIndexFormat;
+  /** The part of a flattened key used for arrays nested directly within exploded arrays. An undefined value is the same as "_" */
+  nestedArrayMarker?: string;
   /**
    * The path to the time. An undefined value or an empty array means that no fields will be interpreted as time fields.
    *
    * If one or multiple of the time paths are blank, those blank time paths should not be treated as time paths and may be disregarded during processing.
    */
   timeFields?: TimeField[];
+  /** When true, fields that look like timestamps are treated as time fields in addition to {@link timeFields}. */
+  autoDetectTimeFields?: boolean;
+  /** Fields computed from the other fields of each row */
+  computedFields?: ComputedField[];
+  /**
+   * An expr expression that each row must match, such as `status == "ok" && value > 0`. Rows that do not match are dropped.
+   * Computed fields may be used in the expression. An undefined or blank value keeps every row.
+   */
+  rowFilter?: string;
+  /** The type of numeric fields. An undefined value is the same as {@link NumberType.FLOAT64} */
+  numberType?: NumberType;
+  /** Numeric options for individual fields, which take precedence over {@link numberType} */
+  numberFields?: NumberField[];
+  /** Type hints for individual fields. Values of a hinted field are coerced to that type */
+  fieldTypes?: FieldTypeHint[];
+  /** What happens when a field has values of different types. An undefined value is the same as {@link MixedTypePolicy.ERROR} */
+  mixedTypePolicy?: MixedTypePolicy;
+  /**
+   * The template used to name frames and value fields, such as "{{host}} {{__field}}".
+   * Supports {{__field}}, {{__refId}}, {{__labels}} and the names of labels. An undefined value uses the default names.
+   */
+  nameTemplate?: string;
+  /** Tables that map the string or boolean values of individual fields to numbers or other strings */
+  valueMaps?: ValueMap[];
+  /** Fields derived from other fields of the same series, such as the rate of a counter */
+  derivedFields?: DerivedField[];
+  /** How series with more points than the max data points of the query are downsampled. An undefined value is the same as {@link DownsampleMethod.NONE} */
+  downsampling?: DownsampleMethod;
+  /** The field used by {@link DownsampleMethod.LTTB} and {@link DownsampleMethod.MIN_MAX} to choose points. An undefined value uses the first numeric field */
+  downsampleFieldPath?: string;
+  /** The order of the rows of each series. An undefined value is the same as {@link SortOrder.NONE} */
+  sortOrder?: SortOrder;
+  /** The field each series is sorted by. An undefined value uses the first time field */
+  sortFieldPath?: string;
+  /** An undefined value is the same as {@link DuplicatePolicy.KEEP} */
+  duplicatePolicy?: DuplicatePolicy;
+  /** The maximum number of rows of each series. An undefined value means there is no limit */
+  rowsPerSeries?: number;
+  /** Whether the first or last rows are kept. An undefined value is the same as {@link RowsFrom.FIRST} */
+  rowsPerSeriesFrom?: RowsFrom;
+  /** The maximum number of series (sets of labels). An undefined value means there is no limit */
+  seriesLimit?: number;
+  /** Whether the series with the highest or lowest aggregate are kept. An undefined value is the same as {@link SeriesRank.TOP} */
+  seriesLimitRank?: SeriesRank;
+  /** The field series are ranked by. An undefined value uses the first numeric field */
+  seriesLimitFieldPath?: string;
+  /** An undefined value is the same as {@link AggregateType.LAST} */
+  seriesLimitAggregate?: AggregateType;
+  /** When true, the series that are not kept are merged into a single series labeled "other" */
+  mergeOtherSeries?: boolean;
+  /** Display options for individual fields */
+  fieldConfigs?: FieldConfig[];
+  /** Globs or /regular expressions/ of fields to include in the data frame. An undefined value or an empty array includes every field */
+  includeFields?: string[];
+  /** Globs or /regular expressions/ of fields to exclude from the data frame, which take precedence over {@link includeFields} */
+  excludeFields?: string[];
   /** The label options. The number of label options and the names of the label options should be consistent between parsing options for the best user experience.*/
   labelOptions?: LabelOption[];
 }